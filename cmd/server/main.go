@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	"log"
+	"time"
 
 	"github.com/lumiere11/pc-inventory-go/database"
 	"github.com/lumiere11/pc-inventory-go/routes"
+	"github.com/lumiere11/pc-inventory-go/workers"
 )
 
 func main() {
@@ -19,6 +22,10 @@ func main() {
 		log.Fatal("Failed to seed database:", err)
 	}
 
+	// Start background workers
+	workers.StartPriceScheduler(context.Background(), db, time.Minute)
+	workers.StartReservationSweeper(context.Background(), db, 30*time.Second)
+
 	// Setup routes
 	router := routes.SetupRoutes(db)
 
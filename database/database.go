@@ -28,12 +28,24 @@ func InitDB() (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	// Category names must be unique before the migration adds their index
+	err = dedupeCategoryNames(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dedupe category names: %w", err)
+	}
+
 	// Run migrations
-	err = db.AutoMigrate(&models.Product{}, &models.Category{}, &models.Status{}, &models.User{})
+	err = db.AutoMigrate(&models.Product{}, &models.Category{}, &models.Status{}, &models.User{}, &models.StockMovement{}, &models.StatusChange{}, &models.ExchangeRate{}, &models.PriceChange{}, &models.ScheduledPrice{}, &models.Warehouse{}, &models.WarehouseStock{}, &models.Reservation{}, &models.LowStockAlert{}, &models.Supplier{}, &models.PurchaseOrder{}, &models.PurchaseOrderLine{}, &models.SalesOrder{}, &models.SalesOrderLine{}, &models.RMA{}, &models.SerialUnit{}, &models.Lot{}, &models.LotMovement{}, &models.Stocktake{}, &models.StocktakeItem{}, &models.StocktakeCount{}, &models.KitComponent{}, &models.ProductSpec{}, &models.Build{}, &models.BuildItem{}, &models.CategoryAttribute{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	// Move legacy float prices to exact minor units
+	err = migrateProductPrices(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate product prices: %w", err)
+	}
+
 	// Seed initial data
 	err = SeedData(db)
 	if err != nil {
@@ -43,6 +55,33 @@ func InitDB() (*gorm.DB, error) {
 	return db, nil
 }
 
+// migrateProductPrices copies the legacy float "price" column into
+// "price_cents" and drops it. It does nothing once the column is gone.
+func migrateProductPrices(db *gorm.DB) error {
+	if !db.Migrator().HasColumn("products", "price") {
+		return nil
+	}
+
+	if err := db.Exec("UPDATE products SET price_cents = ROUND(price * 100)").Error; err != nil {
+		return err
+	}
+
+	return db.Migrator().DropColumn("products", "price")
+}
+
+// dedupeCategoryNames renames every category that shares its name with an
+// older one by appending its ID. It does nothing before the table exists.
+func dedupeCategoryNames(db *gorm.DB) error {
+	if !db.Migrator().HasTable("categories") {
+		return nil
+	}
+
+	return db.Exec(`UPDATE categories c
+		JOIN (SELECT name, MIN(id) AS keep_id FROM categories GROUP BY name HAVING COUNT(*) > 1) d
+		ON c.name = d.name AND c.id <> d.keep_id
+		SET c.name = CONCAT(LEFT(c.name, 85), ' (', c.id, ')')`).Error
+}
+
 // getEnv gets environment variable with fallback to default value
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -42,8 +42,8 @@ func SeedData(db *gorm.DB) error {
 
 	// Seed statuses
 	statuses := []models.Status{
-		{Name: "stock"},
-		{Name: "sold out"},
+		{Name: models.StatusInStock},
+		{Name: models.StatusSoldOut},
 	}
 
 	// Check if statuses already exist
@@ -74,6 +74,20 @@ func SeedData(db *gorm.DB) error {
 		}
 	}
 
+	// Seed the default warehouse
+	var defaultWarehouse models.Warehouse
+	db.Where("is_default = ?", true).First(&defaultWarehouse)
+	if defaultWarehouse.ID == 0 {
+		defaultWarehouse = models.Warehouse{
+			Name:      "Almacen Principal",
+			Code:      "PRINCIPAL",
+			IsDefault: true,
+		}
+		if err := db.WithContext(ctx).Create(&defaultWarehouse).Error; err != nil {
+			return err
+		}
+	}
+
 	// Seed some sample products for testing
 	var productCount int64
 	db.Model(&models.Product{}).Count(&productCount)
@@ -83,7 +97,7 @@ func SeedData(db *gorm.DB) error {
 		var stockStatus models.Status
 		
 		db.Where("name = ?", "Perifericos").First(&peripheralsCategory)
-		db.Where("name = ?", "stock").First(&stockStatus)
+		db.Where("name = ?", models.StatusInStock).First(&stockStatus)
 		
 		if peripheralsCategory.ID > 0 && stockStatus.ID > 0 {
 			sampleProducts := []models.Product{
@@ -93,7 +107,7 @@ func SeedData(db *gorm.DB) error {
 					Model2:      "RZ01-04910100-R3U1",
 					Description: "Gaming mouse with ergonomic design",
 					Stock:       15,
-					Price:       89.99,
+					Price:       8999,
 					StatusID:    stockStatus.ID,
 					CategoryID:  peripheralsCategory.ID,
 				},
@@ -103,7 +117,7 @@ func SeedData(db *gorm.DB) error {
 					Model2:      "RZ03-04860100-R3U1",
 					Description: "Mechanical gaming keyboard",
 					Stock:       8,
-					Price:       199.99,
+					Price:       19999,
 					StatusID:    stockStatus.ID,
 					CategoryID:  peripheralsCategory.ID,
 				},
@@ -113,7 +127,7 @@ func SeedData(db *gorm.DB) error {
 					Model2:      "910-005550",
 					Description: "High performance gaming mouse",
 					Stock:       12,
-					Price:       79.99,
+					Price:       7999,
 					StatusID:    stockStatus.ID,
 					CategoryID:  peripheralsCategory.ID,
 				},
@@ -123,7 +137,7 @@ func SeedData(db *gorm.DB) error {
 					Model2:      "CH-9309011-NA",
 					Description: "FPS gaming mouse with sniper button",
 					Stock:       10,
-					Price:       59.99,
+					Price:       5999,
 					StatusID:    stockStatus.ID,
 					CategoryID:  peripheralsCategory.ID,
 				},
@@ -132,8 +146,65 @@ func SeedData(db *gorm.DB) error {
 			if err := db.WithContext(ctx).Create(&sampleProducts).Error; err != nil {
 				return err
 			}
+
+			// Record the initial stock in the ledger
+			movements := make([]models.StockMovement, 0, len(sampleProducts))
+			for _, product := range sampleProducts {
+				movements = append(movements, models.StockMovement{
+					ProductID:           product.ID,
+					WarehouseID:         defaultWarehouse.ID,
+					Delta:               product.Stock,
+					StockAfter:          product.Stock,
+					WarehouseStockAfter: product.Stock,
+					Reason:              models.StockReasonAdjustment,
+					UserEmail:           "admin@admin.com",
+				})
+			}
+			if err := db.WithContext(ctx).Create(&movements).Error; err != nil {
+				return err
+			}
 		}
 	}
 
+	// Kits have no stock of their own in any warehouse; drop rows an earlier
+	// backfill may have written for them
+	if err := db.WithContext(ctx).Exec(`DELETE FROM warehouse_stocks
+		WHERE product_id IN (SELECT id FROM products WHERE is_kit = true)`).Error; err != nil {
+		return err
+	}
+
+	// Products stocked before warehouses existed keep their stock in the default
+	// one. Kits are left out because their stock comes from their components.
+	if err := db.WithContext(ctx).Exec(`INSERT INTO warehouse_stocks (product_id, warehouse_id, stock, created_at, updated_at)
+		SELECT p.id, ?, p.stock, NOW(), NOW() FROM products p
+		WHERE p.is_kit = false AND NOT EXISTS (SELECT 1 FROM warehouse_stocks ws WHERE ws.product_id = p.id)`, defaultWarehouse.ID).Error; err != nil {
+		return err
+	}
+
+	// Products written before the status followed the stock move to the status
+	// matching their stock, leaving a status change behind
+	stockStatus := "CASE WHEN p.stock > 0 THEN ? ELSE ? END"
+	if err := db.WithContext(ctx).Exec(`INSERT INTO status_changes (product_id, from_status_id, to_status_id, stock_movement_id, user_email, created_at, updated_at)
+		SELECT p.id, p.status_id, s.id, 0, ?, NOW(), NOW() FROM products p
+		JOIN statuses s ON s.name = `+stockStatus+`
+		WHERE p.status_id <> s.id`, "system", models.StatusInStock, models.StatusSoldOut).Error; err != nil {
+		return err
+	}
+	if err := db.WithContext(ctx).Exec(`UPDATE products p
+		JOIN statuses s ON s.name = `+stockStatus+`
+		SET p.status_id = s.id
+		WHERE p.status_id <> s.id`, models.StatusInStock, models.StatusSoldOut).Error; err != nil {
+		return err
+	}
+
+	// Products stocked before the ledger existed get an opening movement, so
+	// summing the ledger rebuilds their stock
+	if err := db.WithContext(ctx).Exec(`INSERT INTO stock_movements (product_id, warehouse_id, delta, stock_after, warehouse_stock_after, reason, user_email, created_at, updated_at)
+		SELECT p.id, ?, p.stock, p.stock, p.stock, ?, ?, NOW(), NOW() FROM products p
+		WHERE p.is_kit = false AND p.stock <> 0 AND NOT EXISTS (SELECT 1 FROM stock_movements sm WHERE sm.product_id = p.id)`,
+		defaultWarehouse.ID, models.StockReasonAdjustment, "system").Error; err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+	"gorm.io/gorm"
+)
+
+type AlertHandler struct {
+	DB *gorm.DB
+}
+
+func NewAlertHandler(db *gorm.DB) *AlertHandler {
+	return &AlertHandler{
+		DB: db,
+	}
+}
+
+// ListLowStock returns low stock alerts, newest first. Pass acknowledged=false
+// to only get the ones still open.
+func (h *AlertHandler) ListLowStock(c *gin.Context) {
+	var alerts []models.LowStockAlert
+	ctx := context.Background()
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := h.DB.WithContext(ctx).Model(&models.LowStockAlert{})
+	switch c.Query("acknowledged") {
+	case "true":
+		query = query.Where("acknowledged_at IS NOT NULL")
+	case "false":
+		query = query.Where("acknowledged_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.Preload("Product").Order("id DESC").Offset(page.Offset()).Limit(page.Limit).Find(&alerts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   alerts,
+		"count":  len(alerts),
+		"page":   page.Page,
+		"limit":  page.Limit,
+		"total":  total,
+	})
+}
+
+// AcknowledgeLowStock closes an alert so a new one can be raised the next
+// time the product drops below its minimum
+func (h *AlertHandler) AcknowledgeLowStock(c *gin.Context) {
+	var alert models.LowStockAlert
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).First(&alert, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Alert not found",
+		})
+		return
+	}
+	if alert.AcknowledgedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    alert,
+			"message": "Alert was already acknowledged",
+		})
+		return
+	}
+
+	// Only the first of concurrent acknowledgements gets through
+	now := time.Now()
+	result := h.DB.WithContext(ctx).Model(&alert).
+		Where("acknowledged_at IS NULL").
+		Updates(map[string]interface{}{
+			"acknowledged_at": now,
+			"acknowledged_by": c.GetString("email"),
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": result.Error.Error(),
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		h.DB.WithContext(ctx).First(&alert, alert.ID)
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    alert,
+			"message": "Alert was already acknowledged",
+		})
+		return
+	}
+	alert.AcknowledgedAt = &now
+	alert.AcknowledgedBy = c.GetString("email")
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    alert,
+		"message": "Alert acknowledged",
+	})
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lumiere11/pc-inventory-go/models"
+	"gorm.io/gorm"
+)
+
+// errAttributes is returned when product attributes do not follow the schema
+// of their category
+var errAttributes = errors.New("invalid attributes")
+
+var attributeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// categorySchema returns the attributes a category defines together with the
+// ones it inherits from its ancestors
+func categorySchema(db *gorm.DB, categoryID uint) ([]models.CategoryAttribute, error) {
+	tree, err := loadCategoryTree(db)
+	if err != nil {
+		return nil, err
+	}
+	ids := tree.ancestors(categoryID)
+	if len(ids) == 0 {
+		return []models.CategoryAttribute{}, nil
+	}
+
+	var attributes []models.CategoryAttribute
+	if err := db.Where("category_id IN ?", ids).Order("name").Find(&attributes).Error; err != nil {
+		return nil, err
+	}
+	return attributes, nil
+}
+
+// attributeNamesUsed returns which of the given attribute names the given
+// categories already define
+func attributeNamesUsed(db *gorm.DB, categoryIDs []uint, names []string) ([]string, error) {
+	used := []string{}
+	if len(categoryIDs) == 0 || len(names) == 0 {
+		return used, nil
+	}
+	if err := db.Model(&models.CategoryAttribute{}).
+		Where("category_id IN ? AND name IN ?", categoryIDs, names).
+		Distinct().
+		Order("name").
+		Pluck("name", &used).Error; err != nil {
+		return nil, err
+	}
+	return used, nil
+}
+
+// attributeNamesStored returns which of the given attribute names the products
+// of the given categories, including the ones in the trash, hold values for
+func attributeNamesStored(db *gorm.DB, categoryIDs []uint, names []string) ([]string, error) {
+	stored := []string{}
+	for _, name := range names {
+		var count int64
+		// Attribute names are plain identifiers, so the JSON path needs no quoting
+		if err := db.Unscoped().Model(&models.Product{}).
+			Where("category_id IN ? AND JSON_EXTRACT(attributes, ?) IS NOT NULL", categoryIDs, "$."+name).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			stored = append(stored, name)
+		}
+	}
+	return stored, nil
+}
+
+// productsBreakingAttribute returns the products of the given categories,
+// including the ones in the trash, whose stored value for the attribute does
+// not fit its definition. Missing values are left for the next write.
+func productsBreakingAttribute(db *gorm.DB, categoryIDs []uint, attribute models.CategoryAttribute) ([]uint, error) {
+	var products []models.Product
+	// Attribute names are plain identifiers, so the JSON path needs no quoting
+	if err := db.Unscoped().
+		Select("id", "attributes").
+		Where("category_id IN ? AND JSON_EXTRACT(attributes, ?) IS NOT NULL", categoryIDs, "$."+attribute.Name).
+		Order("id").
+		Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	broken := []uint{}
+	for _, product := range products {
+		value := product.Attributes[attribute.Name]
+		if value == nil {
+			continue
+		}
+		if _, err := attributeValue(attribute, value); err != nil {
+			broken = append(broken, product.ID)
+		}
+	}
+	return broken, nil
+}
+
+// validateAttributes checks attribute values against a schema and returns
+// them ready to store. Null values count as missing.
+func validateAttributes(schema []models.CategoryAttribute, values map[string]interface{}) (models.Attributes, error) {
+	byName := make(map[string]models.CategoryAttribute, len(schema))
+	for _, attribute := range schema {
+		byName[attribute.Name] = attribute
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	attributes := models.Attributes{}
+	for _, name := range names {
+		value := values[name]
+		if value == nil {
+			continue
+		}
+		attribute, ok := byName[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s is not an attribute of this category", name))
+			continue
+		}
+		normalized, err := attributeValue(attribute, value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s %s", name, err.Error()))
+			continue
+		}
+		attributes[name] = normalized
+	}
+
+	for _, attribute := range schema {
+		if _, ok := attributes[attribute.Name]; attribute.Required && !ok {
+			problems = append(problems, fmt.Sprintf("%s is required", attribute.Name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", errAttributes, strings.Join(problems, "; "))
+	}
+	return attributes, nil
+}
+
+// attributeValue checks a single value against its attribute definition
+func attributeValue(attribute models.CategoryAttribute, value interface{}) (interface{}, error) {
+	switch attribute.Type {
+	case models.AttributeNumber:
+		number, ok := value.(float64)
+		if !ok {
+			return nil, errors.New("must be a number")
+		}
+		return number, nil
+	case models.AttributeInteger:
+		number, ok := value.(float64)
+		if !ok || number != math.Trunc(number) {
+			return nil, errors.New("must be an integer")
+		}
+		return int64(number), nil
+	case models.AttributeBoolean:
+		flag, ok := value.(bool)
+		if !ok {
+			return nil, errors.New("must be true or false")
+		}
+		return flag, nil
+	default:
+		text, ok := value.(string)
+		if !ok {
+			return nil, errors.New("must be a string")
+		}
+		text = strings.TrimSpace(text)
+		if len(attribute.AllowedValues) > 0 && !containsString(attribute.AllowedValues, text) {
+			return nil, fmt.Errorf("must be one of %s", strings.Join(attribute.AllowedValues, ", "))
+		}
+		return text, nil
+	}
+}
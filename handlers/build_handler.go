@@ -0,0 +1,430 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+	"github.com/lumiere11/pc-inventory-go/requests"
+	"gorm.io/gorm"
+)
+
+type BuildHandler struct {
+	DB *gorm.DB
+}
+
+func NewBuildHandler(db *gorm.DB) *BuildHandler {
+	return &BuildHandler{
+		DB: db,
+	}
+}
+
+// Validate checks that a list of products can be assembled into one PC. It
+// reports the incompatibilities found, the estimated power draw and whether
+// every part is in stock.
+func (h *BuildHandler) Validate(c *gin.Context) {
+	var validateReq requests.BuildValidateRequest
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&validateReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	report, err := checkBuild(h.DB.WithContext(ctx), buildItemsFromIDs(validateReq.ProductIDs))
+	if errors.Is(err, errBuildProduct) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   report,
+	})
+}
+
+// List returns the saved builds of the current user, or every build for admins
+func (h *BuildHandler) List(c *gin.Context) {
+	var builds []models.Build
+	ctx := context.Background()
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := h.DB.WithContext(ctx).Model(&models.Build{})
+	if c.GetString("role") != "admin" {
+		query = query.Where("user_email = ?", c.GetString("email"))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.Preload("Items").Order("id DESC").Offset(page.Offset()).Limit(page.Limit).Find(&builds).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   builds,
+		"count":  len(builds),
+		"page":   page.Page,
+		"limit":  page.Limit,
+		"total":  total,
+	})
+}
+
+func (h *BuildHandler) Get(c *gin.Context) {
+	ctx := context.Background()
+
+	build, ok := h.findBuild(ctx, c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   build,
+	})
+}
+
+func (h *BuildHandler) Create(c *gin.Context) {
+	var buildReq requests.BuildRequest
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&buildReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	items, err := h.buildItems(ctx, buildReq)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	build := models.Build{
+		Name:      buildReq.Name,
+		UserEmail: c.GetString("email"),
+		Items:     items,
+	}
+	if err := h.DB.WithContext(ctx).Create(&build).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.respondBuild(ctx, c, http.StatusCreated, build.ID, "Build saved successfully")
+}
+
+// Update renames a build and replaces its products
+func (h *BuildHandler) Update(c *gin.Context) {
+	var buildReq requests.BuildRequest
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&buildReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	build, ok := h.findBuild(ctx, c)
+	if !ok {
+		return
+	}
+
+	items, err := h.buildItems(ctx, buildReq)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err = h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&build).Update("name", buildReq.Name).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("build_id = ?", build.ID).Delete(&models.BuildItem{}).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].BuildID = build.ID
+		}
+		return tx.Create(&items).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.respondBuild(ctx, c, http.StatusOK, build.ID, "Build updated successfully")
+}
+
+func (h *BuildHandler) Delete(c *gin.Context) {
+	ctx := context.Background()
+
+	build, ok := h.findBuild(ctx, c)
+	if !ok {
+		return
+	}
+
+	// Clearing the token keeps shared links from outliving the build
+	if err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&build).Update("share_token", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&build).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    gin.H{"id": build.ID},
+		"message": "Build deleted",
+	})
+}
+
+// Quote totals the current prices of a build in the requested currency and
+// suggests in-stock alternatives for the parts that are short
+func (h *BuildHandler) Quote(c *gin.Context) {
+	ctx := context.Background()
+
+	build, ok := h.findBuild(ctx, c)
+	if !ok {
+		return
+	}
+
+	h.respondQuote(ctx, c, build)
+}
+
+// Share gives a build a token that lets anyone read it. A build that is
+// already shared keeps its token.
+func (h *BuildHandler) Share(c *gin.Context) {
+	ctx := context.Background()
+
+	build, ok := h.findBuild(ctx, c)
+	if !ok {
+		return
+	}
+
+	if build.ShareToken == nil {
+		token, err := newShareToken()
+		if err == nil {
+			err = h.DB.WithContext(ctx).Model(&build).Update("share_token", token).Error
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"data":    gin.H{},
+				"message": err.Error(),
+			})
+			return
+		}
+		build.ShareToken = &token
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    gin.H{"share_token": *build.ShareToken},
+		"message": "Build shared",
+	})
+}
+
+// Unshare revokes the share token of a build
+func (h *BuildHandler) Unshare(c *gin.Context) {
+	ctx := context.Background()
+
+	build, ok := h.findBuild(ctx, c)
+	if !ok {
+		return
+	}
+
+	if err := h.DB.WithContext(ctx).Model(&build).Update("share_token", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    gin.H{"id": build.ID},
+		"message": "Build is no longer shared",
+	})
+}
+
+// Shared returns the quote of a shared build to anyone holding its token
+func (h *BuildHandler) Shared(c *gin.Context) {
+	var build models.Build
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("share_token = ?", c.Param("token")).
+		First(&build).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Build not found",
+		})
+		return
+	}
+
+	h.respondQuote(ctx, c, build)
+}
+
+// buildItems checks the products of a build request and turns them into items
+func (h *BuildHandler) buildItems(ctx context.Context, buildReq requests.BuildRequest) ([]models.BuildItem, error) {
+	items := make([]models.BuildItem, 0, len(buildReq.Items))
+	seen := make(map[uint]bool, len(buildReq.Items))
+	for _, itemReq := range buildReq.Items {
+		if seen[itemReq.ProductID] {
+			return nil, fmt.Errorf("product %d appears in more than one item", itemReq.ProductID)
+		}
+		seen[itemReq.ProductID] = true
+
+		var product models.Product
+		if err := h.DB.WithContext(ctx).Select("id").First(&product, itemReq.ProductID).Error; err != nil {
+			return nil, fmt.Errorf("product %d not found", itemReq.ProductID)
+		}
+		items = append(items, models.BuildItem{
+			ProductID: itemReq.ProductID,
+			Quantity:  itemReq.Quantity,
+		})
+	}
+	return items, nil
+}
+
+// findBuild loads the build named in the path, answering 404 when it does not
+// exist and 403 when it belongs to another user and the caller is not an admin
+func (h *BuildHandler) findBuild(ctx context.Context, c *gin.Context) (models.Build, bool) {
+	var build models.Build
+	if err := h.DB.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Items.Product").
+		First(&build, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Build not found",
+		})
+		return build, false
+	}
+
+	if c.GetString("role") != "admin" && build.UserEmail != c.GetString("email") {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "This build belongs to another user",
+		})
+		return build, false
+	}
+	return build, true
+}
+
+// respondBuild reloads a build with its products and writes it as the response
+func (h *BuildHandler) respondBuild(ctx context.Context, c *gin.Context, status int, buildID uint, message string) {
+	var build models.Build
+	if err := h.DB.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Items.Product").
+		First(&build, buildID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(status, gin.H{
+		"status":  "success",
+		"data":    build,
+		"message": message,
+	})
+}
+
+// respondQuote prices a build in the currency of the query string, or the
+// default currency, and writes the quote as the response
+func (h *BuildHandler) respondQuote(ctx context.Context, c *gin.Context, build models.Build) {
+	currency, err := parseCurrencyParam(c.Query("currency"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+
+	quote, err := quoteBuild(h.DB.WithContext(ctx), build, currency)
+	if errors.Is(err, errNoExchangeRate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   quote,
+	})
+}
+
+// newShareToken returns a random token for a shared build
+func newShareToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
@@ -0,0 +1,469 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lumiere11/pc-inventory-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// psuHeadroomPercent is the margin recommended above the estimated power draw
+const psuHeadroomPercent = 25
+
+// errBuildProduct is returned when a build names a missing product
+var errBuildProduct = errors.New("product not found")
+
+// buildItem is a product and the number of units a build uses
+type buildItem struct {
+	ProductID uint
+	Quantity  int32
+}
+
+// buildPart is a product of a build with its stock situation
+type buildPart struct {
+	ProductID     uint   `json:"product_id"`
+	Name          string `json:"name"`
+	CategoryID    uint   `json:"category_id"`
+	ComponentType string `json:"component_type"`
+	Quantity      int32  `json:"quantity"`
+	Available     int32  `json:"available"`
+	InStock       bool   `json:"in_stock"`
+}
+
+// buildIssue is an incompatibility between parts of a build
+type buildIssue struct {
+	Type       string `json:"type"`
+	Message    string `json:"message"`
+	ProductIDs []uint `json:"product_ids"`
+}
+
+// buildReport is the outcome of checking a build. A build is buildable when
+// its parts are compatible and all of them are in stock.
+type buildReport struct {
+	Parts                 []buildPart  `json:"parts"`
+	Incompatibilities     []buildIssue `json:"incompatibilities"`
+	Warnings              []string     `json:"warnings"`
+	EstimatedPowerDraw    int32        `json:"estimated_power_draw"`
+	RecommendedPSUWattage int32        `json:"recommended_psu_wattage"`
+	PSUWattage            int32        `json:"psu_wattage"`
+	Compatible            bool         `json:"compatible"`
+	Buildable             bool         `json:"buildable"`
+}
+
+// buildComponent is a physical part of a build. Kits are checked through the
+// components they contain.
+type buildComponent struct {
+	product       models.Product
+	componentType string
+	quantity      int32
+}
+
+// buildItemsFromIDs counts a list of product IDs, where a repeated ID means
+// more units of the same product
+func buildItemsFromIDs(ids []uint) []buildItem {
+	var items []buildItem
+	index := make(map[uint]int, len(ids))
+	for _, id := range ids {
+		if i, ok := index[id]; ok {
+			items[i].Quantity++
+			continue
+		}
+		index[id] = len(items)
+		items = append(items, buildItem{ProductID: id, Quantity: 1})
+	}
+	return items
+}
+
+// checkBuild compares the technical attributes of the parts of a build and
+// estimates its power draw
+func checkBuild(db *gorm.DB, items []buildItem) (buildReport, error) {
+	report := buildReport{
+		Parts:             []buildPart{},
+		Incompatibilities: []buildIssue{},
+		Warnings:          []string{},
+	}
+
+	ids := make([]uint, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductID)
+	}
+	var products []models.Product
+	if err := db.Preload("Spec").Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return report, err
+	}
+	if err := fillAvailability(db, products); err != nil {
+		return report, err
+	}
+	byID := make(map[uint]models.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	tree, err := loadCategoryTree(db)
+	if err != nil {
+		return report, err
+	}
+
+	var kitIDs []uint
+	for _, item := range items {
+		product, ok := byID[item.ProductID]
+		if !ok {
+			return report, fmt.Errorf("%w: %d", errBuildProduct, item.ProductID)
+		}
+		if product.IsKit {
+			kitIDs = append(kitIDs, product.ID)
+		}
+		report.Parts = append(report.Parts, buildPart{
+			ProductID:     product.ID,
+			Name:          product.Name,
+			CategoryID:    product.CategoryID,
+			ComponentType: tree.componentType(product.CategoryID),
+			Quantity:      item.Quantity,
+			Available:     product.Available,
+			InStock:       product.Available >= item.Quantity,
+		})
+	}
+
+	kitComponents := make(map[uint][]models.KitComponent, len(kitIDs))
+	if len(kitIDs) > 0 {
+		var components []models.KitComponent
+		if err := db.Preload("Component.Spec").Where("kit_id IN ?", kitIDs).Order("component_id").Find(&components).Error; err != nil {
+			return report, err
+		}
+		for _, component := range components {
+			kitComponents[component.KitID] = append(kitComponents[component.KitID], component)
+		}
+	}
+
+	var parts []buildComponent
+	for _, item := range items {
+		product := byID[item.ProductID]
+		if !product.IsKit {
+			parts = append(parts, buildComponent{
+				product:       product,
+				componentType: tree.componentType(product.CategoryID),
+				quantity:      item.Quantity,
+			})
+			continue
+		}
+		for _, component := range kitComponents[product.ID] {
+			if component.Component == nil {
+				continue
+			}
+			parts = append(parts, buildComponent{
+				product:       *component.Component,
+				componentType: tree.componentType(component.Component.CategoryID),
+				quantity:      item.Quantity * component.Quantity,
+			})
+		}
+	}
+
+	report.compare(parts)
+
+	report.Compatible = len(report.Incompatibilities) == 0
+	report.Buildable = report.Compatible
+	for _, part := range report.Parts {
+		if !part.InStock {
+			report.Buildable = false
+		}
+	}
+	return report, nil
+}
+
+// compare runs the compatibility rules over the physical parts of a build
+func (r *buildReport) compare(parts []buildComponent) {
+	byType := make(map[string][]buildComponent)
+	for _, part := range parts {
+		if part.componentType == "" {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("%s is not in a component category and was not checked", part.product.Name))
+			continue
+		}
+		if part.product.Spec == nil {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("%s has no technical specs and was not checked", part.product.Name))
+		}
+		byType[part.componentType] = append(byType[part.componentType], part)
+	}
+
+	for _, componentType := range []string{models.ComponentCPU, models.ComponentMotherboard, models.ComponentPSU, models.ComponentCase} {
+		var units int32
+		var ids []uint
+		for _, part := range byType[componentType] {
+			units += part.quantity
+			ids = append(ids, part.product.ID)
+		}
+		if units > 1 {
+			r.addIssue("part_count", fmt.Sprintf("A build takes a single %s, found %d", componentType, units), ids...)
+		}
+	}
+	for _, componentType := range []string{models.ComponentCPU, models.ComponentMotherboard, models.ComponentMemory, models.ComponentPSU} {
+		if len(byType[componentType]) == 0 {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("The build has no %s", componentType))
+		}
+	}
+
+	for _, board := range withSpec(byType[models.ComponentMotherboard]) {
+		for _, cpu := range withSpec(byType[models.ComponentCPU]) {
+			if !specValuesMatch(cpu.product.Spec.Socket, board.product.Spec.Socket) {
+				r.addIssue("socket", fmt.Sprintf("%s uses socket %s but %s has socket %s",
+					cpu.product.Name, cpu.product.Spec.Socket, board.product.Name, board.product.Spec.Socket),
+					cpu.product.ID, board.product.ID)
+			}
+		}
+		for _, memory := range withSpec(byType[models.ComponentMemory]) {
+			if !specValuesMatch(memory.product.Spec.MemoryType, board.product.Spec.MemoryType) {
+				r.addIssue("memory_type", fmt.Sprintf("%s is %s but %s takes %s",
+					memory.product.Name, memory.product.Spec.MemoryType, board.product.Name, board.product.Spec.MemoryType),
+					memory.product.ID, board.product.ID)
+			}
+		}
+		for _, pcCase := range withSpec(byType[models.ComponentCase]) {
+			if board.product.Spec.FormFactor != "" && pcCase.product.Spec.SupportedFormFactors != "" &&
+				!specListContains(pcCase.product.Spec.SupportedFormFactors, board.product.Spec.FormFactor) {
+				r.addIssue("form_factor", fmt.Sprintf("%s does not fit a %s motherboard like %s",
+					pcCase.product.Name, board.product.Spec.FormFactor, board.product.Name),
+					pcCase.product.ID, board.product.ID)
+			}
+		}
+	}
+
+	for _, cooler := range withSpec(byType[models.ComponentCooler]) {
+		for _, cpu := range withSpec(byType[models.ComponentCPU]) {
+			if cpu.product.Spec.Socket != "" && cooler.product.Spec.SupportedSockets != "" &&
+				!specListContains(cooler.product.Spec.SupportedSockets, cpu.product.Spec.Socket) {
+				r.addIssue("cooler_socket", fmt.Sprintf("%s does not support socket %s of %s",
+					cooler.product.Name, cpu.product.Spec.Socket, cpu.product.Name),
+					cooler.product.ID, cpu.product.ID)
+			}
+		}
+	}
+
+	for _, gpu := range withSpec(byType[models.ComponentGPU]) {
+		for _, pcCase := range withSpec(byType[models.ComponentCase]) {
+			if gpu.product.Spec.LengthMM > 0 && pcCase.product.Spec.MaxGPULengthMM > 0 &&
+				gpu.product.Spec.LengthMM > pcCase.product.Spec.MaxGPULengthMM {
+				r.addIssue("gpu_length", fmt.Sprintf("%s is %d mm long but %s fits cards up to %d mm",
+					gpu.product.Name, gpu.product.Spec.LengthMM, pcCase.product.Name, pcCase.product.Spec.MaxGPULengthMM),
+					gpu.product.ID, pcCase.product.ID)
+			}
+		}
+	}
+
+	var psuIDs []uint
+	for _, part := range withSpec(parts) {
+		if part.componentType == models.ComponentPSU {
+			r.PSUWattage += part.product.Spec.Wattage * part.quantity
+			psuIDs = append(psuIDs, part.product.ID)
+			continue
+		}
+		r.EstimatedPowerDraw += part.product.Spec.PowerDraw * part.quantity
+	}
+	r.RecommendedPSUWattage = (r.EstimatedPowerDraw*(100+psuHeadroomPercent) + 99) / 100
+	if len(psuIDs) > 0 {
+		if r.PSUWattage < r.EstimatedPowerDraw {
+			r.addIssue("power", fmt.Sprintf("The power supply gives %d W but the build draws about %d W",
+				r.PSUWattage, r.EstimatedPowerDraw), psuIDs...)
+		} else if r.PSUWattage < r.RecommendedPSUWattage {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("The power supply gives %d W; %d W is recommended",
+				r.PSUWattage, r.RecommendedPSUWattage))
+		}
+	}
+}
+
+// addIssue records an incompatibility between the given products
+func (r *buildReport) addIssue(issueType, message string, productIDs ...uint) {
+	sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+	r.Incompatibilities = append(r.Incompatibilities, buildIssue{
+		Type:       issueType,
+		Message:    message,
+		ProductIDs: productIDs,
+	})
+}
+
+// withSpec returns the parts that have technical specs
+func withSpec(parts []buildComponent) []buildComponent {
+	var result []buildComponent
+	for _, part := range parts {
+		if part.product.Spec != nil {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+// specValuesMatch compares two attribute values, treating an unknown value as
+// a match so missing data does not report false incompatibilities
+func specValuesMatch(a, b string) bool {
+	if a == "" || b == "" {
+		return true
+	}
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// specListContains reports whether a comma separated attribute list holds a value
+func specListContains(list, value string) bool {
+	for _, item := range strings.Split(list, ",") {
+		if strings.EqualFold(strings.TrimSpace(item), strings.TrimSpace(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxBuildAlternatives is how many in-stock replacements are suggested for a
+// part that is short of stock
+const maxBuildAlternatives = 3
+
+// buildAlternative is an in-stock product that can replace a part of a build
+type buildAlternative struct {
+	ProductID uint         `json:"product_id"`
+	Name      string       `json:"name"`
+	Price     models.Money `json:"price"`
+	Available int32        `json:"available"`
+}
+
+// buildQuoteLine is a priced part of a build
+type buildQuoteLine struct {
+	ProductID    uint               `json:"product_id"`
+	Name         string             `json:"name"`
+	CategoryID   uint               `json:"category_id"`
+	Quantity     int32              `json:"quantity"`
+	UnitPrice    models.Money       `json:"unit_price"`
+	LineTotal    models.Money       `json:"line_total"`
+	Available    int32              `json:"available"`
+	InStock      bool               `json:"in_stock"`
+	Alternatives []buildAlternative `json:"alternatives,omitempty"`
+}
+
+// buildQuote totals the current prices of a build in one currency. Products
+// removed from the catalog since the build was saved are listed apart.
+type buildQuote struct {
+	BuildID           uint             `json:"build_id"`
+	Name              string           `json:"name"`
+	Currency          string           `json:"currency"`
+	Lines             []buildQuoteLine `json:"lines"`
+	Total             models.Money     `json:"total"`
+	AllInStock        bool             `json:"all_in_stock"`
+	RemovedProductIDs []uint           `json:"removed_product_ids"`
+	Compatibility     buildReport      `json:"compatibility"`
+}
+
+// quoteBuild prices a saved build at current prices, suggests alternatives
+// for the parts that are short of stock and checks the parts fit together
+func quoteBuild(db *gorm.DB, build models.Build, currency string) (buildQuote, error) {
+	quote := buildQuote{
+		BuildID:           build.ID,
+		Name:              build.Name,
+		Currency:          currency,
+		Lines:             []buildQuoteLine{},
+		AllInStock:        true,
+		RemovedProductIDs: []uint{},
+	}
+
+	ids := make([]uint, 0, len(build.Items))
+	for _, item := range build.Items {
+		ids = append(ids, item.ProductID)
+	}
+	var products []models.Product
+	if err := db.Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return quote, err
+	}
+	if err := fillAvailability(db, products); err != nil {
+		return quote, err
+	}
+	converter := newCurrencyConverter(db, currency)
+	if err := converter.convertProducts(products); err != nil {
+		return quote, err
+	}
+	byID := make(map[uint]models.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	var items []buildItem
+	for _, item := range build.Items {
+		product, ok := byID[item.ProductID]
+		if !ok {
+			quote.RemovedProductIDs = append(quote.RemovedProductIDs, item.ProductID)
+			quote.AllInStock = false
+			continue
+		}
+		items = append(items, buildItem{ProductID: item.ProductID, Quantity: item.Quantity})
+
+		line := buildQuoteLine{
+			ProductID:  product.ID,
+			Name:       product.Name,
+			CategoryID: product.CategoryID,
+			Quantity:   item.Quantity,
+			UnitPrice:  product.Price,
+			LineTotal:  product.Price * models.Money(item.Quantity),
+			Available:  product.Available,
+			InStock:    product.Available >= item.Quantity,
+		}
+		if !line.InStock {
+			quote.AllInStock = false
+			alternatives, err := buildAlternatives(db, converter, product, item.Quantity)
+			if err != nil {
+				return quote, err
+			}
+			line.Alternatives = alternatives
+		}
+		quote.Total += line.LineTotal
+		quote.Lines = append(quote.Lines, line)
+	}
+
+	if len(items) > 0 {
+		report, err := checkBuild(db, items)
+		if err != nil {
+			return quote, err
+		}
+		quote.Compatibility = report
+	}
+	return quote, nil
+}
+
+// buildAlternatives finds products of the same category that have the
+// quantity available, closest in price first. The product passed in already
+// has its price converted, so its original price is read again for ordering.
+// Prices can only be compared in the same currency, so candidates are limited
+// to the currency of the original product.
+func buildAlternatives(db *gorm.DB, converter *currencyConverter, product models.Product, quantity int32) ([]buildAlternative, error) {
+	var original models.Product
+	if err := db.Select("id", "price_cents", "currency").First(&original, product.ID).Error; err != nil {
+		return nil, err
+	}
+
+	var candidates []models.Product
+	if err := db.Where("category_id = ? AND id <> ? AND currency = ? AND stock >= ?", product.CategoryID, product.ID, original.Currency, quantity).
+		Clauses(clause.OrderBy{Expression: clause.Expr{SQL: "ABS(price_cents - ?), id", Vars: []interface{}{int64(original.Price)}}}).
+		Limit(maxBuildAlternatives * 3).
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+	if err := fillAvailability(db, candidates); err != nil {
+		return nil, err
+	}
+	if err := converter.convertProducts(candidates); err != nil {
+		return nil, err
+	}
+
+	alternatives := []buildAlternative{}
+	for _, candidate := range candidates {
+		if candidate.Available < quantity {
+			continue
+		}
+		alternatives = append(alternatives, buildAlternative{
+			ProductID: candidate.ID,
+			Name:      candidate.Name,
+			Price:     candidate.Price,
+			Available: candidate.Available,
+		})
+		if len(alternatives) == maxBuildAlternatives {
+			break
+		}
+	}
+	return alternatives, nil
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+	"github.com/lumiere11/pc-inventory-go/requests"
+	"gorm.io/gorm"
+)
+
+type CategoryAttributeHandler struct {
+	DB *gorm.DB
+}
+
+func NewCategoryAttributeHandler(db *gorm.DB) *CategoryAttributeHandler {
+	return &CategoryAttributeHandler{
+		DB: db,
+	}
+}
+
+// List returns the attribute schema of a category, including the attributes
+// inherited from its ancestors. The category_id of each attribute tells where
+// it is defined.
+func (h *CategoryAttributeHandler) List(c *gin.Context) {
+	ctx := context.Background()
+
+	category, ok := h.findCategory(ctx, c)
+	if !ok {
+		return
+	}
+
+	schema, err := categorySchema(h.DB.WithContext(ctx), category.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   schema,
+		"count":  len(schema),
+	})
+}
+
+// Create adds an attribute to a category. The name must not already be used
+// by the category, its ancestors or its subcategories.
+func (h *CategoryAttributeHandler) Create(c *gin.Context) {
+	var attributeReq requests.CategoryAttributeRequest
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&attributeReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	category, ok := h.findCategory(ctx, c)
+	if !ok {
+		return
+	}
+
+	if !attributeNamePattern.MatchString(attributeReq.Name) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Attribute names use lowercase letters, digits and underscores, starting with a letter",
+		})
+		return
+	}
+	if err := checkAllowedValues(attributeReq.Type, attributeReq.AllowedValues); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	tree, err := loadCategoryTree(h.DB.WithContext(ctx))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	related := append(tree.ancestors(category.ID), tree.descendants(category.ID)[1:]...)
+	used, err := attributeNamesUsed(h.DB.WithContext(ctx), related, []string{attributeReq.Name})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(used) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "An attribute with this name already exists in this category, a parent or a subcategory",
+		})
+		return
+	}
+
+	attribute := models.CategoryAttribute{
+		CategoryID:    category.ID,
+		Name:          attributeReq.Name,
+		Type:          attributeReq.Type,
+		Unit:          attributeReq.Unit,
+		AllowedValues: models.StringList(attributeReq.AllowedValues),
+		Required:      attributeReq.Required,
+	}
+	if err := h.DB.WithContext(ctx).Create(&attribute).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"data":    attribute,
+		"message": "Attribute created successfully",
+	})
+}
+
+// Update changes the type, unit, allowed values and required flag of an
+// attribute. The change is refused while products of the category or its
+// subcategories store values the new definition would reject; products
+// missing a newly required value are asked for it on their next write.
+func (h *CategoryAttributeHandler) Update(c *gin.Context) {
+	var attributeReq requests.UpdateCategoryAttributeRequest
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&attributeReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	attribute, ok := h.findAttribute(ctx, c)
+	if !ok {
+		return
+	}
+	if err := checkAllowedValues(attributeReq.Type, attributeReq.AllowedValues); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	attribute.Type = attributeReq.Type
+	attribute.Unit = attributeReq.Unit
+	attribute.AllowedValues = models.StringList(attributeReq.AllowedValues)
+	attribute.Required = attributeReq.Required
+
+	tree, err := loadCategoryTree(h.DB.WithContext(ctx))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	broken, err := productsBreakingAttribute(h.DB.WithContext(ctx), tree.descendants(attribute.CategoryID), attribute)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(broken) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{"products": broken},
+			"message": "Some products store values this definition would reject",
+		})
+		return
+	}
+
+	if err := h.DB.WithContext(ctx).Model(&attribute).Updates(map[string]interface{}{
+		"type":           attribute.Type,
+		"unit":           attribute.Unit,
+		"allowed_values": attribute.AllowedValues,
+		"required":       attribute.Required,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    attribute,
+		"message": "Attribute updated successfully",
+	})
+}
+
+// Delete removes an attribute and its values from the products of the
+// category and its subcategories, including the ones in the trash
+func (h *CategoryAttributeHandler) Delete(c *gin.Context) {
+	ctx := context.Background()
+
+	attribute, ok := h.findAttribute(ctx, c)
+	if !ok {
+		return
+	}
+
+	tree, err := loadCategoryTree(h.DB.WithContext(ctx))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&attribute).Error; err != nil {
+			return err
+		}
+		// Attribute names are plain identifiers, so the JSON path needs no quoting
+		return tx.Unscoped().Model(&models.Product{}).
+			Where("category_id IN ? AND attributes IS NOT NULL", tree.descendants(attribute.CategoryID)).
+			UpdateColumn("attributes", gorm.Expr("JSON_REMOVE(attributes, ?)", "$."+attribute.Name)).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    gin.H{"id": attribute.ID},
+		"message": "Attribute deleted successfully",
+	})
+}
+
+func (h *CategoryAttributeHandler) findCategory(ctx context.Context, c *gin.Context) (models.Category, bool) {
+	var category models.Category
+	if err := h.DB.WithContext(ctx).First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Category not found",
+		})
+		return category, false
+	}
+	return category, true
+}
+
+// findAttribute loads the attribute named in the path, which must be defined
+// by the category in the path itself
+func (h *CategoryAttributeHandler) findAttribute(ctx context.Context, c *gin.Context) (models.CategoryAttribute, bool) {
+	var attribute models.CategoryAttribute
+	err := h.DB.WithContext(ctx).
+		Where("id = ? AND category_id = ?", c.Param("attributeId"), c.Param("id")).
+		First(&attribute).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Attribute not found",
+		})
+		return attribute, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return attribute, false
+	}
+	return attribute, true
+}
+
+// checkAllowedValues makes sure enums list their values and that only text
+// attributes restrict them
+func checkAllowedValues(attributeType string, allowed []string) error {
+	switch attributeType {
+	case models.AttributeEnum:
+		if len(allowed) == 0 {
+			return errors.New("an enum attribute needs allowed_values")
+		}
+	case models.AttributeString:
+	default:
+		if len(allowed) > 0 {
+			return fmt.Errorf("allowed_values only apply to string and enum attributes, not %s", attributeType)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,541 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+	"github.com/lumiere11/pc-inventory-go/requests"
+	"gorm.io/gorm"
+)
+
+type CategoryHandler struct {
+	DB *gorm.DB
+}
+
+func NewCategoryHandler(db *gorm.DB) *CategoryHandler {
+	return &CategoryHandler{
+		DB: db,
+	}
+}
+
+// categoryResponse is a category along with the number of products it holds
+type categoryResponse struct {
+	models.Category
+	ProductCount int64 `json:"product_count"`
+}
+
+// productCounts returns the number of products per category ID
+func productCounts(db *gorm.DB, categoryIDs ...uint) (map[uint]int64, error) {
+	var rows []struct {
+		CategoryID uint
+		Total      int64
+	}
+	query := db.Model(&models.Product{}).Select("category_id, COUNT(*) AS total").Group("category_id")
+	if len(categoryIDs) > 0 {
+		query = query.Where("category_id IN ?", categoryIDs)
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.CategoryID] = row.Total
+	}
+	return counts, nil
+}
+
+// List returns every category with its product count
+func (h *CategoryHandler) List(c *gin.Context) {
+	var categories []models.Category
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).Order("name").Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	counts, err := productCounts(h.DB.WithContext(ctx))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	data := make([]categoryResponse, 0, len(categories))
+	for _, category := range categories {
+		data = append(data, categoryResponse{Category: category, ProductCount: counts[category.ID]})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   data,
+		"count":  len(data),
+	})
+}
+
+// categoryNode is a category with its subcategories nested below it
+type categoryNode struct {
+	categoryResponse
+	Children []*categoryNode `json:"children"`
+}
+
+// Tree returns the category hierarchy as nested nodes
+func (h *CategoryHandler) Tree(c *gin.Context) {
+	ctx := context.Background()
+
+	tree, err := loadCategoryTree(h.DB.WithContext(ctx))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	counts, err := productCounts(h.DB.WithContext(ctx))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var build func(id uint) *categoryNode
+	build = func(id uint) *categoryNode {
+		node := &categoryNode{
+			categoryResponse: categoryResponse{Category: tree.categories[id], ProductCount: counts[id]},
+			Children:         []*categoryNode{},
+		}
+		for _, child := range tree.children[id] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+
+	roots := tree.roots()
+	sort.Slice(roots, func(i, j int) bool {
+		return tree.categories[roots[i]].Name < tree.categories[roots[j]].Name
+	})
+	data := make([]*categoryNode, 0, len(roots))
+	for _, id := range roots {
+		data = append(data, build(id))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   data,
+		"count":  len(data),
+	})
+}
+
+// Get returns a single category with its product count
+func (h *CategoryHandler) Get(c *gin.Context) {
+	var category models.Category
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Category not found",
+		})
+		return
+	}
+
+	counts, err := productCounts(h.DB.WithContext(ctx), category.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   categoryResponse{Category: category, ProductCount: counts[category.ID]},
+	})
+}
+
+func (h *CategoryHandler) Create(c *gin.Context) {
+	var categoryReq requests.CategoryRequest
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&categoryReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if h.nameTaken(ctx, categoryReq.Name, 0) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "A category with this name already exists",
+		})
+		return
+	}
+
+	if categoryReq.ParentID != nil {
+		tree, err := loadCategoryTree(h.DB.WithContext(ctx))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if _, ok := tree.categories[*categoryReq.ParentID]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found"})
+			return
+		}
+		if tree.depth(*categoryReq.ParentID)+1 > maxCategoryDepth {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Categories cannot be nested more than %d levels deep", maxCategoryDepth)})
+			return
+		}
+	}
+
+	category := models.Category{
+		Name:                   categoryReq.Name,
+		ParentID:               categoryReq.ParentID,
+		DefaultMinStock:        categoryReq.DefaultMinStock,
+		DefaultReorderQuantity: categoryReq.DefaultReorderQuantity,
+		ComponentType:          categoryReq.ComponentType,
+	}
+	if err := h.DB.WithContext(ctx).Create(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"data":    categoryResponse{Category: category},
+		"message": "Category created successfully",
+	})
+}
+
+// Update renames a category and sets its low stock defaults and component type
+func (h *CategoryHandler) Update(c *gin.Context) {
+	var categoryReq requests.CategoryRequest
+	var category models.Category
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&categoryReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.DB.WithContext(ctx).First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Category not found",
+		})
+		return
+	}
+
+	if categoryReq.ParentID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "parent_id cannot be changed here, use PUT /api/v1/categories/:id/parent",
+		})
+		return
+	}
+
+	if h.nameTaken(ctx, categoryReq.Name, category.ID) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "A category with this name already exists",
+		})
+		return
+	}
+
+	category.Name = categoryReq.Name
+	category.DefaultMinStock = categoryReq.DefaultMinStock
+	category.DefaultReorderQuantity = categoryReq.DefaultReorderQuantity
+	category.ComponentType = categoryReq.ComponentType
+	if err := h.DB.WithContext(ctx).Model(&category).Updates(map[string]interface{}{
+		"name":                     category.Name,
+		"default_min_stock":        category.DefaultMinStock,
+		"default_reorder_quantity": category.DefaultReorderQuantity,
+		"component_type":           category.ComponentType,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	counts, err := productCounts(h.DB.WithContext(ctx), category.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    categoryResponse{Category: category, ProductCount: counts[category.ID]},
+		"message": "Category updated successfully",
+	})
+}
+
+// Move changes the parent of a category, or makes it a root when parent_id is null
+func (h *CategoryHandler) Move(c *gin.Context) {
+	var moveReq requests.MoveCategoryRequest
+	var category models.Category
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&moveReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.DB.WithContext(ctx).First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Category not found",
+		})
+		return
+	}
+
+	tree, err := loadCategoryTree(h.DB.WithContext(ctx))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	parentDepth := 0
+	if moveReq.ParentID != nil {
+		if _, ok := tree.categories[*moveReq.ParentID]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found"})
+			return
+		}
+		// A category cannot be moved below itself or one of its descendants
+		if tree.isDescendant(*moveReq.ParentID, category.ID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot move a category into its own subtree"})
+			return
+		}
+		parentDepth = tree.depth(*moveReq.ParentID)
+	}
+	if parentDepth+tree.height(category.ID) > maxCategoryDepth {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Categories cannot be nested more than %d levels deep", maxCategoryDepth)})
+		return
+	}
+
+	// The subtree inherits the attributes of its new parents, so their names
+	// must not clash with the ones it defines
+	var newParents []uint
+	if moveReq.ParentID != nil {
+		newParents = tree.ancestors(*moveReq.ParentID)
+		var names []string
+		if err := h.DB.WithContext(ctx).Model(&models.CategoryAttribute{}).
+			Where("category_id IN ?", tree.descendants(category.ID)).
+			Pluck("name", &names).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		used, err := attributeNamesUsed(h.DB.WithContext(ctx), newParents, names)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(used) > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"status":  "error",
+				"data":    gin.H{"attributes": used},
+				"message": "The new parent categories already define attributes with these names",
+			})
+			return
+		}
+	}
+
+	// It also stops inheriting the attributes of the parents it leaves, and
+	// the values its products hold for them would no longer be valid
+	if category.ParentID != nil {
+		kept := make(map[uint]bool, len(newParents))
+		for _, id := range newParents {
+			kept[id] = true
+		}
+		var leftParents []uint
+		for _, id := range tree.ancestors(*category.ParentID) {
+			if !kept[id] {
+				leftParents = append(leftParents, id)
+			}
+		}
+		var names []string
+		if len(leftParents) > 0 {
+			if err := h.DB.WithContext(ctx).Model(&models.CategoryAttribute{}).
+				Where("category_id IN ?", leftParents).
+				Order("name").
+				Pluck("name", &names).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		stored, err := attributeNamesStored(h.DB.WithContext(ctx), tree.descendants(category.ID), names)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(stored) > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"status":  "error",
+				"data":    gin.H{"attributes": stored},
+				"message": "Products below this category hold values for attributes it would no longer inherit",
+			})
+			return
+		}
+	}
+
+	if err := h.DB.WithContext(ctx).Model(&category).Update("parent_id", moveReq.ParentID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+	category.ParentID = moveReq.ParentID
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    category,
+		"message": "Category moved successfully",
+	})
+}
+
+// Delete removes a category, refusing when products still belong to it
+func (h *CategoryHandler) Delete(c *gin.Context) {
+	var category models.Category
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Category not found",
+		})
+		return
+	}
+
+	var productCount int64
+	if err := h.DB.WithContext(ctx).Model(&models.Product{}).Where("category_id = ?", category.ID).Count(&productCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if productCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{"product_count": productCount},
+			"message": "Category still has products",
+		})
+		return
+	}
+
+	var childCount int64
+	if err := h.DB.WithContext(ctx).Model(&models.Category{}).Where("parent_id = ?", category.ID).Count(&childCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if childCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{"child_count": childCount},
+			"message": "Category still has subcategories",
+		})
+		return
+	}
+
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("category_id = ?", category.ID).Delete(&models.CategoryAttribute{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&category).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    gin.H{},
+		"message": "Category deleted successfully",
+	})
+}
+
+// Restore brings back a deleted category under its former parent. Its
+// attributes were removed along with it, so it comes back without them.
+func (h *CategoryHandler) Restore(c *gin.Context) {
+	var category models.Category
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Category not found in trash",
+		})
+		return
+	}
+
+	if category.ParentID != nil {
+		tree, err := loadCategoryTree(h.DB.WithContext(ctx))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if _, ok := tree.categories[*category.ParentID]; !ok {
+			c.JSON(http.StatusConflict, gin.H{
+				"status":  "error",
+				"data":    gin.H{},
+				"message": "The parent of this category no longer exists",
+			})
+			return
+		}
+		// The parent may have moved deeper while this category was deleted
+		if tree.depth(*category.ParentID)+1 > maxCategoryDepth {
+			c.JSON(http.StatusConflict, gin.H{
+				"status":  "error",
+				"data":    gin.H{},
+				"message": fmt.Sprintf("Categories cannot be nested more than %d levels deep", maxCategoryDepth),
+			})
+			return
+		}
+	}
+
+	if err := h.DB.WithContext(ctx).Unscoped().Model(&category).Update("deleted_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+	category.DeletedAt = gorm.DeletedAt{}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    categoryResponse{Category: category},
+		"message": "Category restored successfully",
+	})
+}
+
+// nameTaken reports whether another category already uses the given name.
+// Deleted categories count too: the unique index still covers them and they
+// can be restored under their name.
+func (h *CategoryHandler) nameTaken(ctx context.Context, name string, excludeID uint) bool {
+	var existing models.Category
+	err := h.DB.WithContext(ctx).Unscoped().Where("name = ? AND id <> ?", name, excludeID).First(&existing).Error
+	return !errors.Is(err, gorm.ErrRecordNotFound)
+}
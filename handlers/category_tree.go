@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/lumiere11/pc-inventory-go/models"
+	"gorm.io/gorm"
+)
+
+// maxCategoryDepth is the deepest level a category can sit at, roots being level one
+const maxCategoryDepth = 5
+
+// categoryTree is an in-memory view of the category hierarchy
+type categoryTree struct {
+	categories map[uint]models.Category
+	children   map[uint][]uint
+}
+
+// loadCategoryTree reads every category and indexes it by parent
+func loadCategoryTree(db *gorm.DB) (*categoryTree, error) {
+	var categories []models.Category
+	if err := db.Order("name").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	tree := &categoryTree{
+		categories: make(map[uint]models.Category, len(categories)),
+		children:   make(map[uint][]uint),
+	}
+	for _, category := range categories {
+		tree.categories[category.ID] = category
+		if category.ParentID != nil {
+			tree.children[*category.ParentID] = append(tree.children[*category.ParentID], category.ID)
+		}
+	}
+	return tree, nil
+}
+
+// roots returns the IDs of the categories without a parent
+func (t *categoryTree) roots() []uint {
+	var ids []uint
+	for id, category := range t.categories {
+		if category.ParentID == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// depth returns the level of a category, roots being level one
+func (t *categoryTree) depth(id uint) int {
+	depth := 0
+	// The bound guards against looping forever on a corrupted hierarchy
+	for depth <= len(t.categories) {
+		category, ok := t.categories[id]
+		if !ok {
+			break
+		}
+		depth++
+		if category.ParentID == nil {
+			break
+		}
+		id = *category.ParentID
+	}
+	return depth
+}
+
+// height returns the number of levels of the subtree rooted at a category
+func (t *categoryTree) height(id uint) int {
+	height := 0
+	for _, child := range t.children[id] {
+		if h := t.height(child); h > height {
+			height = h
+		}
+	}
+	return height + 1
+}
+
+// descendants returns the ID of a category followed by the IDs of every
+// category below it
+func (t *categoryTree) descendants(id uint) []uint {
+	ids := []uint{id}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, t.children[ids[i]]...)
+	}
+	return ids
+}
+
+// isDescendant reports whether id sits in the subtree rooted at ancestor
+func (t *categoryTree) isDescendant(id, ancestor uint) bool {
+	for _, descendant := range t.descendants(ancestor) {
+		if descendant == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ancestors returns the ID of a category followed by the IDs of its parent,
+// grandparent and so on up to the root
+func (t *categoryTree) ancestors(id uint) []uint {
+	var ids []uint
+	// The bound guards against looping forever on a corrupted hierarchy
+	for len(ids) <= len(t.categories) {
+		category, ok := t.categories[id]
+		if !ok {
+			break
+		}
+		ids = append(ids, id)
+		if category.ParentID == nil {
+			break
+		}
+		id = *category.ParentID
+	}
+	return ids
+}
+
+// componentType returns the component type of a category, inherited from the
+// nearest ancestor that sets one
+func (t *categoryTree) componentType(id uint) string {
+	for _, ancestor := range t.ancestors(id) {
+		if componentType := t.categories[ancestor].ComponentType; componentType != "" {
+			return componentType
+		}
+	}
+	return ""
+}
+
+// categorySubtreeIDs resolves a category by ID or name and returns the IDs of
+// its whole subtree
+func categorySubtreeIDs(db *gorm.DB, category string) ([]uint, error) {
+	var root models.Category
+	query := db.Where("name = ?", category)
+	if id, err := strconv.ParseUint(category, 10, 64); err == nil {
+		query = db.Where("id = ?", id)
+	}
+	if err := query.First(&root).Error; err != nil {
+		return nil, err
+	}
+
+	tree, err := loadCategoryTree(db)
+	if err != nil {
+		return nil, err
+	}
+	return tree.descendants(root.ID), nil
+}
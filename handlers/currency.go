@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lumiere11/pc-inventory-go/models"
+	"gorm.io/gorm"
+)
+
+// errNoExchangeRate is returned when no rate is stored for a currency pair
+var errNoExchangeRate = errors.New("no exchange rate available")
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// parseCurrencyParam normalizes a currency query parameter, returning an empty
+// string when none was given
+func parseCurrencyParam(value string) (string, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" {
+		return "", nil
+	}
+	if !currencyCodePattern.MatchString(value) {
+		return "", fmt.Errorf("currency '%s' is not a valid ISO 4217 code", value)
+	}
+	return value, nil
+}
+
+// findExchangeRate returns the rate in effect at the given time to convert from
+// one currency to another. A rate stored for the inverse pair is inverted.
+func findExchangeRate(db *gorm.DB, from, to string, at time.Time) (*big.Rat, *models.ExchangeRate, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil, nil
+	}
+
+	var rate models.ExchangeRate
+	err := db.Where("((base_currency = ? AND quote_currency = ?) OR (base_currency = ? AND quote_currency = ?)) AND effective_from <= ?",
+		from, to, to, from, at).
+		Order("effective_from DESC").
+		Order("id DESC").
+		First(&rate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, fmt.Errorf("%w from %s to %s", errNoExchangeRate, from, to)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	value, ok := new(big.Rat).SetString(rate.Rate)
+	if !ok || value.Sign() <= 0 {
+		return nil, nil, fmt.Errorf("invalid exchange rate %d: %s", rate.ID, rate.Rate)
+	}
+	if rate.BaseCurrency != from {
+		value.Inv(value)
+	}
+	return value, &rate, nil
+}
+
+// convertMoney multiplies an amount by a rate, rounding half up to the nearest
+// minor unit
+func convertMoney(amount models.Money, rate *big.Rat) models.Money {
+	value := new(big.Rat).Mul(new(big.Rat).SetInt64(int64(amount)), rate)
+	num := new(big.Int).Mul(value.Num(), big.NewInt(2))
+	num.Add(num, value.Denom())
+	den := new(big.Int).Mul(value.Denom(), big.NewInt(2))
+	return models.Money(new(big.Int).Div(num, den).Int64())
+}
+
+// currencyConverter converts product prices to a target currency, caching the
+// rate of every source currency it meets
+type currencyConverter struct {
+	db     *gorm.DB
+	target string
+	at     time.Time
+	rates  map[string]*big.Rat
+}
+
+func newCurrencyConverter(db *gorm.DB, target string) *currencyConverter {
+	return &currencyConverter{db: db, target: target, at: time.Now(), rates: map[string]*big.Rat{}}
+}
+
+// convertProducts rewrites the price and currency of each product in place
+func (cv *currencyConverter) convertProducts(products []models.Product) error {
+	for i := range products {
+		rate, ok := cv.rates[products[i].Currency]
+		if !ok {
+			var err error
+			rate, _, err = findExchangeRate(cv.db, products[i].Currency, cv.target, cv.at)
+			if err != nil {
+				return err
+			}
+			cv.rates[products[i].Currency] = rate
+		}
+		products[i].Price = convertMoney(products[i].Price, rate)
+		products[i].Currency = cv.target
+	}
+	return nil
+}
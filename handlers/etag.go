@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+)
+
+// productETag returns the entity tag of the current version of a product
+func productETag(product models.Product) string {
+	return fmt.Sprintf(`"%d-%d"`, product.ID, product.Version)
+}
+
+// checkIfMatch validates the If-Match header of a write against the current
+// version of a product. It answers 428 when the header is missing and 412 when
+// it does not match, returning false in both cases. If-Match uses the strong
+// comparison, so weak tags never match.
+func checkIfMatch(c *gin.Context, product models.Product) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "The If-Match header is required to modify this product",
+		})
+		return false
+	}
+
+	current := productETag(product)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+
+	respondVersionMismatch(c, product)
+	return false
+}
+
+// respondVersionMismatch answers 412 with the entity tag the client should
+// refetch
+func respondVersionMismatch(c *gin.Context, product models.Product) {
+	c.Header("ETag", productETag(product))
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"status":  "error",
+		"data":    gin.H{},
+		"message": "The product was modified by someone else, reload it and try again",
+	})
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+	"github.com/lumiere11/pc-inventory-go/requests"
+	"gorm.io/gorm"
+)
+
+// maxExchangeRate is the first value too large for the decimal(18,6) rate column
+var maxExchangeRate = new(big.Rat).SetInt64(1_000_000_000_000)
+
+type ExchangeRateHandler struct {
+	DB *gorm.DB
+}
+
+func NewExchangeRateHandler(db *gorm.DB) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		DB: db,
+	}
+}
+
+// List returns every stored rate version, newest first, optionally filtered
+// by base and quote currency
+func (h *ExchangeRateHandler) List(c *gin.Context) {
+	var rates []models.ExchangeRate
+	ctx := context.Background()
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := h.DB.WithContext(ctx).Model(&models.ExchangeRate{})
+	if base, err := parseCurrencyParam(c.Query("base")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if base != "" {
+		query = query.Where("base_currency = ?", base)
+	}
+	if quote, err := parseCurrencyParam(c.Query("quote")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if quote != "" {
+		query = query.Where("quote_currency = ?", quote)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.Order("effective_from DESC").Order("id DESC").Offset(page.Offset()).Limit(page.Limit).Find(&rates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   rates,
+		"count":  len(rates),
+		"page":   page.Page,
+		"limit":  page.Limit,
+		"total":  total,
+	})
+}
+
+// Create stores a new version of the rate between two currencies
+func (h *ExchangeRateHandler) Create(c *gin.Context) {
+	var rateReq requests.ExchangeRateRequest
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&rateReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	value, ok := new(big.Rat).SetString(rateReq.Rate)
+	if !ok || value.Sign() <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid rate value",
+		})
+		return
+	}
+	// The column keeps six decimals and twelve integer digits, so check the
+	// value that will actually be stored
+	stored := value.FloatString(6)
+	rounded, _ := new(big.Rat).SetString(stored)
+	if rounded.Sign() <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Rate must be at least 0.000001",
+		})
+		return
+	}
+	if rounded.Cmp(maxExchangeRate) >= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Rate must be below 1000000000000",
+		})
+		return
+	}
+
+	effectiveFrom := time.Now()
+	if rateReq.EffectiveFrom != nil {
+		effectiveFrom = *rateReq.EffectiveFrom
+	}
+
+	rate := models.ExchangeRate{
+		BaseCurrency:  rateReq.BaseCurrency,
+		QuoteCurrency: rateReq.QuoteCurrency,
+		Rate:          stored,
+		EffectiveFrom: effectiveFrom,
+		CreatedBy:     c.GetString("email"),
+	}
+	if err := h.DB.WithContext(ctx).Create(&rate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"data":    rate,
+		"message": "Exchange rate created successfully",
+	})
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+	"github.com/lumiere11/pc-inventory-go/requests"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errKitComponent is returned when a component cannot be part of a kit
+var errKitComponent = errors.New("invalid kit component")
+
+type KitHandler struct {
+	DB *gorm.DB
+}
+
+func NewKitHandler(db *gorm.DB) *KitHandler {
+	return &KitHandler{
+		DB: db,
+	}
+}
+
+// SetComponents replaces the components of a kit and recomputes its stock.
+// Like any other product write it requires If-Match.
+// Components must be ordinary products: kits cannot contain other kits, and
+// serialized products are left out because a kit sale names no serials.
+func (h *KitHandler) SetComponents(c *gin.Context) {
+	var componentsReq requests.KitComponentsRequest
+	var kit models.Product
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&componentsReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.DB.WithContext(ctx).First(&kit, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
+		})
+		return
+	}
+	if !kit.IsKit {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Product is not a kit",
+		})
+		return
+	}
+	if !checkIfMatch(c, kit) {
+		return
+	}
+
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.Product
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Select("id", "version").First(&current, kit.ID).Error; err != nil {
+			return err
+		}
+		if current.Version != kit.Version {
+			return errVersionMismatch
+		}
+
+		components := make([]models.KitComponent, 0, len(componentsReq.Components))
+		seen := make(map[uint]bool, len(componentsReq.Components))
+		for _, componentReq := range componentsReq.Components {
+			if componentReq.ProductID == kit.ID {
+				return fmt.Errorf("%w: a kit cannot contain itself", errKitComponent)
+			}
+			if seen[componentReq.ProductID] {
+				return fmt.Errorf("%w: product %d appears more than once", errKitComponent, componentReq.ProductID)
+			}
+			seen[componentReq.ProductID] = true
+
+			var product models.Product
+			if err := tx.Select("id", "is_kit", "serialized").First(&product, componentReq.ProductID).Error; err != nil {
+				return fmt.Errorf("%w: product %d not found", errKitComponent, componentReq.ProductID)
+			}
+			if product.IsKit {
+				return fmt.Errorf("%w: product %d is a kit", errKitComponent, product.ID)
+			}
+			if product.Serialized {
+				return fmt.Errorf("%w: product %d is serialized", errKitComponent, product.ID)
+			}
+			components = append(components, models.KitComponent{
+				KitID:       kit.ID,
+				ComponentID: product.ID,
+				Quantity:    componentReq.Quantity,
+			})
+		}
+
+		if err := tx.Where("kit_id = ?", kit.ID).Delete(&models.KitComponent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&components).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Product{}).Where("id = ?", kit.ID).
+			Update("version", gorm.Expr("version + 1")).Error; err != nil {
+			return err
+		}
+		return refreshKit(tx, kit.ID, models.StockMovement{UserEmail: c.GetString("email")})
+	})
+	if errors.Is(err, errVersionMismatch) || errors.Is(err, gorm.ErrRecordNotFound) {
+		if h.DB.WithContext(ctx).First(&kit, kit.ID).Error != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"data":    gin.H{},
+				"message": "Product not found",
+			})
+			return
+		}
+		respondVersionMismatch(c, kit)
+		return
+	}
+	if errors.Is(err, errKitComponent) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.DB.WithContext(ctx).
+		Preload("Category").
+		Preload("Status").
+		Preload("Components.Component").
+		First(&kit, kit.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.Header("ETag", productETag(kit))
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    kit,
+		"message": "Kit components updated successfully",
+	})
+}
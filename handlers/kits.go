@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lumiere11/pc-inventory-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errKitStock is returned when a stock change targets a kit directly
+var errKitStock = errors.New("kit stock is derived from its components")
+
+// buildableKits returns how many complete kits the component stock levels allow
+func buildableKits(components []models.KitComponent, stock map[uint]int32) int32 {
+	if len(components) == 0 {
+		return 0
+	}
+	buildable := int32(-1)
+	for _, component := range components {
+		units := stock[component.ComponentID] / component.Quantity
+		if units < 0 {
+			units = 0
+		}
+		if buildable < 0 || units < buildable {
+			buildable = units
+		}
+	}
+	return buildable
+}
+
+// refreshKits recomputes the stock and status of every kit that uses the
+// product moved by a movement
+func refreshKits(tx *gorm.DB, movement models.StockMovement) error {
+	var kitIDs []uint
+	if err := tx.Model(&models.KitComponent{}).
+		Where("component_id = ?", movement.ProductID).
+		Order("kit_id").
+		Pluck("kit_id", &kitIDs).Error; err != nil {
+		return err
+	}
+	for _, kitID := range kitIDs {
+		if err := refreshKit(tx, kitID, movement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshKit stores the stock a kit can currently be built with and moves it to
+// the matching status. The movement that triggered the refresh is recorded on
+// the status change.
+func refreshKit(tx *gorm.DB, kitID uint, trigger models.StockMovement) error {
+	var kit models.Product
+	err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Select("id", "stock").First(&kit, kitID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Kits in the trash are refreshed when restored
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var components []models.KitComponent
+	if err := tx.Where("kit_id = ?", kitID).Find(&components).Error; err != nil {
+		return err
+	}
+	ids := make([]uint, 0, len(components))
+	for _, component := range components {
+		ids = append(ids, component.ComponentID)
+	}
+
+	stock := make(map[uint]int32, len(ids))
+	if len(ids) > 0 {
+		var products []models.Product
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthShare}).
+			Select("id", "stock").Where("id IN ?", ids).Find(&products).Error; err != nil {
+			return err
+		}
+		for _, product := range products {
+			stock[product.ID] = product.Stock
+		}
+	}
+
+	buildable := buildableKits(components, stock)
+	if buildable == kit.Stock {
+		return nil
+	}
+	if err := tx.Model(&models.Product{}).Where("id = ?", kit.ID).Updates(map[string]interface{}{
+		"stock":   buildable,
+		"version": gorm.Expr("version + 1"),
+	}).Error; err != nil {
+		return err
+	}
+
+	trigger.ProductID = kit.ID
+	trigger.StockAfter = buildable
+	return syncProductStatus(tx, trigger)
+}
+
+// applyProductChange applies a stock change to a product, or to each component
+// of a kit scaled by the quantity the kit uses, and returns the movements made
+func applyProductChange(tx *gorm.DB, change stockChange) ([]models.StockMovement, error) {
+	var product models.Product
+	if err := tx.Select("id", "is_kit").First(&product, change.ProductID).Error; err != nil {
+		return nil, err
+	}
+	if !product.IsKit {
+		movement, err := applyStockChange(tx, change)
+		if err != nil {
+			return nil, err
+		}
+		return []models.StockMovement{movement}, nil
+	}
+
+	if len(change.Serials) > 0 {
+		return nil, fmt.Errorf("%w: kit %d is not serialized", errSerials, product.ID)
+	}
+
+	var components []models.KitComponent
+	if err := tx.Where("kit_id = ?", product.ID).Order("component_id").Find(&components).Error; err != nil {
+		return nil, err
+	}
+	if len(components) == 0 {
+		return nil, fmt.Errorf("%w: kit %d has no components", errKitStock, product.ID)
+	}
+
+	movements := make([]models.StockMovement, 0, len(components))
+	for _, component := range components {
+		componentChange := change
+		componentChange.ProductID = component.ComponentID
+		componentChange.Delta = change.Delta * component.Quantity
+		componentChange.ExpectedVersion = 0
+		movement, err := applyStockChange(tx, componentChange)
+		if err != nil {
+			return nil, fmt.Errorf("component %d: %w", component.ComponentID, err)
+		}
+		movements = append(movements, movement)
+	}
+	return movements, nil
+}
+
+// kitComponentsByKit loads the components of the given kits, keyed by kit
+func kitComponentsByKit(db *gorm.DB, kitIDs ...uint) (map[uint][]models.KitComponent, error) {
+	byKit := make(map[uint][]models.KitComponent, len(kitIDs))
+	if len(kitIDs) == 0 {
+		return byKit, nil
+	}
+	var components []models.KitComponent
+	if err := db.Where("kit_id IN ?", kitIDs).Order("component_id").Find(&components).Error; err != nil {
+		return nil, err
+	}
+	for _, component := range components {
+		byKit[component.KitID] = append(byKit[component.KitID], component)
+	}
+	return byKit, nil
+}
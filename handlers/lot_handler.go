@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+	"gorm.io/gorm"
+)
+
+type LotHandler struct {
+	DB *gorm.DB
+}
+
+func NewLotHandler(db *gorm.DB) *LotHandler {
+	return &LotHandler{
+		DB: db,
+	}
+}
+
+// ProductLots returns the lots of a product in the order they are consumed.
+// Empty lots are left out unless all=true.
+func (h *LotHandler) ProductLots(c *gin.Context) {
+	var product models.Product
+	var lots []models.Lot
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
+		})
+		return
+	}
+
+	query := h.DB.WithContext(ctx).Where("product_id = ?", product.ID)
+	if c.Query("all") != "true" {
+		query = query.Where("remaining > 0")
+	}
+	if warehouseID := c.Query("warehouse_id"); warehouseID != "" {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+	if err := query.Order("warehouse_id").Order("received_at").Order("id").Find(&lots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   lots,
+		"count":  len(lots),
+	})
+}
+
+// Movements returns how each stock movement took from or added to a lot
+func (h *LotHandler) Movements(c *gin.Context) {
+	var lot models.Lot
+	var movements []models.LotMovement
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).First(&lot, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Lot not found",
+		})
+		return
+	}
+
+	if err := h.DB.WithContext(ctx).Where("lot_id = ?", lot.ID).Order("id").Find(&movements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"lot":       lot,
+			"movements": movements,
+		},
+	})
+}
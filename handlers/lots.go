@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lumiere11/pc-inventory-go/models"
+	"github.com/lumiere11/pc-inventory-go/requests"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errLots is returned when lot details do not fit the product or the lots
+// cannot cover a movement
+var errLots = errors.New("invalid lot")
+
+// lotDetails describes the lot that incoming units of a lot-tracked product
+// belong to
+type lotDetails struct {
+	LotNumber         string
+	SupplierID        *uint
+	SupplierReference string
+	WarrantyExpiresAt *time.Time
+}
+
+// lotFromRequest turns the lot of a request into lot details, returning nil
+// when the request has none
+func lotFromRequest(lotReq *requests.LotRequest, supplierID *uint) *lotDetails {
+	if lotReq == nil {
+		if supplierID == nil {
+			return nil
+		}
+		return &lotDetails{SupplierID: supplierID}
+	}
+	return &lotDetails{
+		LotNumber:         lotReq.LotNumber,
+		SupplierID:        supplierID,
+		SupplierReference: lotReq.SupplierReference,
+		WarrantyExpiresAt: lotReq.WarrantyExpiresAt,
+	}
+}
+
+// checkTrackingModes rejects products that are both serialized and lot tracked
+func checkTrackingModes(serialized, lotTracked bool) error {
+	if serialized && lotTracked {
+		return fmt.Errorf("%w: a product cannot be serialized and lot tracked at once", errLots)
+	}
+	return nil
+}
+
+// applyLotChange keeps the lots of a lot-tracked product in step with a
+// movement: incoming units open a lot, outgoing units are taken from the
+// oldest lots of the warehouse first
+func applyLotChange(tx *gorm.DB, product models.Product, movement models.StockMovement, lot *lotDetails) error {
+	if !product.LotTracked {
+		if lot != nil && (lot.LotNumber != "" || lot.SupplierReference != "" || lot.WarrantyExpiresAt != nil) {
+			return fmt.Errorf("%w: product %d is not lot tracked", errLots, product.ID)
+		}
+		return nil
+	}
+
+	if movement.Delta > 0 {
+		if lot == nil {
+			lot = &lotDetails{}
+		}
+		newLot := models.Lot{
+			ProductID:         product.ID,
+			WarehouseID:       movement.WarehouseID,
+			LotNumber:         lot.LotNumber,
+			SupplierID:        lot.SupplierID,
+			SupplierReference: lot.SupplierReference,
+			ReceivedAt:        time.Now(),
+			WarrantyExpiresAt: lot.WarrantyExpiresAt,
+			Quantity:          movement.Delta,
+			Remaining:         movement.Delta,
+		}
+		if err := tx.Create(&newLot).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.LotMovement{
+			StockMovementID: movement.ID,
+			LotID:           newLot.ID,
+			Quantity:        movement.Delta,
+		}).Error
+	}
+
+	_, err := consumeLots(tx, product.ID, movement.WarehouseID, -movement.Delta, movement.ID)
+	return err
+}
+
+// consumeLots takes units from the lots of a product in a warehouse, oldest
+// first, and returns the lots it took from with the units taken from each
+func consumeLots(tx *gorm.DB, productID, warehouseID uint, quantity int32, movementID uint) ([]models.LotMovement, error) {
+	var lots []models.Lot
+	if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Where("product_id = ? AND warehouse_id = ? AND remaining > 0", productID, warehouseID).
+		Order("received_at").
+		Order("id").
+		Find(&lots).Error; err != nil {
+		return nil, err
+	}
+
+	var taken []models.LotMovement
+	for _, lot := range lots {
+		if quantity == 0 {
+			break
+		}
+		take := lot.Remaining
+		if take > quantity {
+			take = quantity
+		}
+		if err := tx.Model(&lot).Update("remaining", lot.Remaining-take).Error; err != nil {
+			return nil, err
+		}
+		taken = append(taken, models.LotMovement{StockMovementID: movementID, LotID: lot.ID, Quantity: -take})
+		quantity -= take
+	}
+	if quantity > 0 {
+		return nil, fmt.Errorf("%w: lots of product %d in warehouse %d are short by %d units", errLots, productID, warehouseID, quantity)
+	}
+
+	if len(taken) > 0 {
+		if err := tx.Create(&taken).Error; err != nil {
+			return nil, err
+		}
+	}
+	return taken, nil
+}
+
+// transferLots moves units of a lot-tracked product between warehouses,
+// oldest lots first. Each lot keeps its number, dates and supplier in the
+// destination warehouse.
+func transferLots(tx *gorm.DB, productID uint, out, in models.StockMovement) error {
+	taken, err := consumeLots(tx, productID, out.WarehouseID, -out.Delta, out.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, take := range taken {
+		var source models.Lot
+		if err := tx.First(&source, take.LotID).Error; err != nil {
+			return err
+		}
+		moved := models.Lot{
+			ProductID:         productID,
+			WarehouseID:       in.WarehouseID,
+			LotNumber:         source.LotNumber,
+			SupplierID:        source.SupplierID,
+			SupplierReference: source.SupplierReference,
+			ReceivedAt:        source.ReceivedAt,
+			WarrantyExpiresAt: source.WarrantyExpiresAt,
+			Quantity:          -take.Quantity,
+			Remaining:         -take.Quantity,
+		}
+		if err := tx.Create(&moved).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.LotMovement{
+			StockMovementID: in.ID,
+			LotID:           moved.ID,
+			Quantity:        moved.Quantity,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
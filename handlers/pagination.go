@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// pagination holds the page and limit parsed from the query string
+type pagination struct {
+	Page  int
+	Limit int
+}
+
+// Offset returns the number of rows to skip for the current page
+func (p pagination) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// parsePagination reads the page and limit query parameters, falling back to
+// the first page and the default limit
+func parsePagination(c *gin.Context) (pagination, error) {
+	p := pagination{Page: 1, Limit: defaultPageLimit}
+
+	if page := c.Query("page"); page != "" {
+		value, err := strconv.Atoi(page)
+		if err != nil || value < 1 {
+			return p, errors.New("page must be a positive integer")
+		}
+		p.Page = value
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > maxPageLimit {
+			return p, errors.New("limit must be between 1 and 100")
+		}
+		p.Limit = value
+	}
+
+	return p, nil
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+	"github.com/lumiere11/pc-inventory-go/requests"
+	"gorm.io/gorm"
+)
+
+type PriceHandler struct {
+	DB *gorm.DB
+}
+
+func NewPriceHandler(db *gorm.DB) *PriceHandler {
+	return &PriceHandler{
+		DB: db,
+	}
+}
+
+// findProduct loads the product identified by the path ID, answering 404 when
+// it does not exist
+func (h *PriceHandler) findProduct(ctx context.Context, c *gin.Context) (models.Product, bool) {
+	var product models.Product
+	if err := h.DB.WithContext(ctx).First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
+		})
+		return product, false
+	}
+	return product, true
+}
+
+// GetHistory returns the price changes of a product, newest first
+func (h *PriceHandler) GetHistory(c *gin.Context) {
+	ctx := context.Background()
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	product, ok := h.findProduct(ctx, c)
+	if !ok {
+		return
+	}
+
+	var total int64
+	query := h.DB.WithContext(ctx).Model(&models.PriceChange{}).Where("product_id = ?", product.ID)
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var changes []models.PriceChange
+	if err := query.Order("id DESC").Offset(page.Offset()).Limit(page.Limit).Find(&changes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   changes,
+		"count":  len(changes),
+		"page":   page.Page,
+		"limit":  page.Limit,
+		"total":  total,
+	})
+}
+
+// ListScheduled returns the scheduled prices of a product. Pass
+// pending=true to only get the ones that have not been applied or cancelled.
+func (h *PriceHandler) ListScheduled(c *gin.Context) {
+	ctx := context.Background()
+
+	product, ok := h.findProduct(ctx, c)
+	if !ok {
+		return
+	}
+
+	query := h.DB.WithContext(ctx).Where("product_id = ?", product.ID)
+	if c.Query("pending") == "true" {
+		query = query.Where("applied_at IS NULL AND cancelled_at IS NULL")
+	}
+
+	var schedules []models.ScheduledPrice
+	if err := query.Order("effective_at").Find(&schedules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   schedules,
+		"count":  len(schedules),
+	})
+}
+
+// Schedule registers a price that the scheduler applies once effective_at is reached
+func (h *PriceHandler) Schedule(c *gin.Context) {
+	var scheduleReq requests.ScheduledPriceRequest
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&scheduleReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	price, err := models.ParseMoney(scheduleReq.Price)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid price value",
+		})
+		return
+	}
+	if !scheduleReq.EffectiveAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "effective_at must be in the future",
+		})
+		return
+	}
+
+	product, ok := h.findProduct(ctx, c)
+	if !ok {
+		return
+	}
+
+	currency := product.Currency
+	if scheduleReq.Currency != "" {
+		currency = scheduleReq.Currency
+	}
+
+	schedule := models.ScheduledPrice{
+		ProductID:   product.ID,
+		Price:       price,
+		Currency:    currency,
+		EffectiveAt: scheduleReq.EffectiveAt,
+		CreatedBy:   c.GetString("email"),
+	}
+	if err := h.DB.WithContext(ctx).Create(&schedule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"data":    schedule,
+		"message": "Price change scheduled successfully",
+	})
+}
+
+// CancelScheduled cancels a scheduled price that has not been applied yet
+func (h *PriceHandler) CancelScheduled(c *gin.Context) {
+	var schedule models.ScheduledPrice
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).
+		Where("id = ? AND product_id = ?", c.Param("scheduleId"), c.Param("id")).
+		First(&schedule).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Scheduled price not found",
+		})
+		return
+	}
+
+	now := time.Now()
+	result := h.DB.WithContext(ctx).Model(&schedule).
+		Where("applied_at IS NULL AND cancelled_at IS NULL").
+		Update("cancelled_at", now)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": result.Error.Error(),
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    schedule,
+			"message": "Scheduled price was already applied or cancelled",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    schedule,
+		"message": "Scheduled price cancelled",
+	})
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
@@ -12,6 +13,7 @@ import (
 	"github.com/lumiere11/pc-inventory-go/models"
 	"github.com/lumiere11/pc-inventory-go/requests"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductHandler struct {
@@ -89,7 +91,12 @@ func (h *ProductHandler) GetByProperty(c *gin.Context) {
 	q := c.Query("q")
 	status := c.Query("status")
 	if status == "" {
-		status = "stock"
+		status = models.StatusInStock
+	}
+	currency, err := parseCurrencyParam(c.Query("currency"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	fmt.Printf("Search query: %s, Status: %s\n", q, status)
@@ -97,7 +104,9 @@ func (h *ProductHandler) GetByProperty(c *gin.Context) {
 	// Build the query step by step
 	query := h.DB.WithContext(ctx).Model(&models.Product{}).
 		Preload("Category").
-		Preload("Status")
+		Preload("Status").
+		Preload("Components.Component").
+		Preload("Spec")
 
 	// Add search filters if query parameter is provided
 	if q != "" {
@@ -122,6 +131,21 @@ func (h *ProductHandler) GetByProperty(c *gin.Context) {
 		query = query.Where("status_id = ?", statusModel.ID)
 	}
 
+	// Filter by category, including every subcategory below it
+	var categoryIDs []uint
+	if category := c.Query("category"); category != "" {
+		ids, err := categorySubtreeIDs(h.DB.WithContext(ctx), category)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid category parameter",
+				"details": fmt.Sprintf("Category '%s' not found", category),
+			})
+			return
+		}
+		categoryIDs = ids
+		query = query.Where("category_id IN ?", categoryIDs)
+	}
+
 	// Execute the query
 	if err := query.Find(&products).Error; err != nil {
 		fmt.Printf("Database error: %v\n", err)
@@ -139,13 +163,18 @@ func (h *ProductHandler) GetByProperty(c *gin.Context) {
 		// Reuse base query but without the text filter, keeping status and preloads
 		baseQuery := h.DB.WithContext(ctx).Model(&models.Product{}).
 			Preload("Category").
-			Preload("Status")
+			Preload("Status").
+			Preload("Components.Component").
+			Preload("Spec")
 		if status != "" {
 			var statusModel models.Status
 			if err := h.DB.Where("name = ?", status).First(&statusModel).Error; err == nil {
 				baseQuery = baseQuery.Where("status_id = ?", statusModel.ID)
 			}
 		}
+		if categoryIDs != nil {
+			baseQuery = baseQuery.Where("category_id IN ?", categoryIDs)
+		}
 
 		if err := baseQuery.Find(&candidates).Error; err != nil {
 			fmt.Printf("Fuzzy fallback DB error: %v\n", err)
@@ -212,12 +241,139 @@ func (h *ProductHandler) GetByProperty(c *gin.Context) {
 	}
 
 	fmt.Printf("Found %d products\n", len(products))
+
+	if err := fillAvailability(h.DB.WithContext(ctx), products); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if currency != "" {
+		if err := newCurrencyConverter(h.DB.WithContext(ctx), currency).convertProducts(products); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   products,
+		"count":  len(products),
+	})
+}
+
+// productNameTaken reports whether a product outside the trash already uses
+// the given name
+func productNameTaken(db *gorm.DB, name string, excludeID uint) bool {
+	var existing models.Product
+	err := db.Where("name = ? AND id <> ?", name, excludeID).First(&existing).Error
+	return !errors.Is(err, gorm.ErrRecordNotFound)
+}
+
+// productSortColumns maps the accepted sort keys to their database columns
+var productSortColumns = map[string]string{
+	"name":       "name",
+	"price":      "price_cents",
+	"stock":      "stock",
+	"created_at": "created_at",
+}
+
+// List returns a page of products sorted by name, price, stock or created_at
+func (h *ProductHandler) List(c *gin.Context) {
+	var products []models.Product
+	ctx := context.Background()
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sortKey := c.DefaultQuery("sort", "created_at")
+	column, ok := productSortColumns[sortKey]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of name, price, stock or created_at"})
+		return
+	}
+	order := strings.ToLower(c.DefaultQuery("order", "asc"))
+	if order != "asc" && order != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must be asc or desc"})
+		return
+	}
+	currency, err := parseCurrencyParam(c.Query("currency"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var total int64
+	if err := h.DB.WithContext(ctx).Model(&models.Product{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Sort by id as well so pages stay stable when values repeat
+	if err := h.DB.WithContext(ctx).
+		Preload("Category").
+		Preload("Status").
+		Order(column + " " + order).
+		Order("id " + order).
+		Offset(page.Offset()).
+		Limit(page.Limit).
+		Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := fillAvailability(h.DB.WithContext(ctx), products); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if currency != "" {
+		if err := newCurrencyConverter(h.DB.WithContext(ctx), currency).convertProducts(products); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data":   products,
 		"count":  len(products),
+		"page":   page.Page,
+		"limit":  page.Limit,
+		"total":  total,
+	})
+}
+
+// GetProduct returns a single product with its category and status
+func (h *ProductHandler) GetProduct(c *gin.Context) {
+	var product models.Product
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).Preload("Category").Preload("Status").Preload("Components.Component").Preload("Spec").First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
+		})
+		return
+	}
+
+	products := []models.Product{product}
+	if err := fillAvailability(h.DB.WithContext(ctx), products); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	product = products[0]
+
+	c.Header("ETag", productETag(product))
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   product,
 	})
 }
+
 func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	var productReq requests.ProductRequest
 	ctx := context.Background()
@@ -229,12 +385,37 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
+	// Names only need to be unique among products that are not in the trash
+	if productNameTaken(h.DB.WithContext(ctx), productReq.Name, 0) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "A product with this name already exists",
+		})
+		return
+	}
+
 	// Convert ProductRequest to Product model with proper type conversion
 	product := models.Product{
 		Name:        productReq.Name,
 		Brand:       productReq.Brand,
 		Model2:      productReq.Model2,
 		Description: productReq.Description,
+		Serialized:  productReq.Serialized,
+		LotTracked:  productReq.LotTracked,
+		IsKit:       productReq.IsKit,
+	}
+	if err := checkTrackingModes(product.Serialized, product.LotTracked); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if product.IsKit && (product.Serialized || product.LotTracked) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A kit cannot be serialized or lot tracked",
+		})
+		return
 	}
 
 	// Convert string fields to appropriate types
@@ -243,20 +424,35 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 			"error": "Invalid stock value",
 		})
 		return
+	} else if stock < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Stock cannot be negative",
+		})
+		return
 	} else {
 		product.Stock = int32(stock)
 	}
+	// A kit starts empty and gets its stock once its components are set
+	if product.IsKit && product.Stock != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A kit's stock is derived from its components and must start at 0",
+		})
+		return
+	}
 
-	if price, err := strconv.ParseFloat(productReq.Price, 32); err != nil {
+	if price, err := models.ParseMoney(productReq.Price); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Invalid price value",
 		})
 		return
 	} else {
-		product.Price = float32(price)
+		product.Price = price
 	}
 
-	product.StatusID = 1
+	product.Currency = models.DefaultCurrency
+	if productReq.Currency != "" {
+		product.Currency = productReq.Currency
+	}
 
 	if categoryID, err := strconv.ParseUint(productReq.CategoryID, 10, 32); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -267,13 +463,57 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		product.CategoryID = uint(categoryID)
 	}
 
-	// Create the product in database
-	result := h.DB.WithContext(ctx).Create(&product)
-	if result.Error != nil {
+	schema, err := categorySchema(h.DB.WithContext(ctx), product.CategoryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	attributes, err := validateAttributes(schema, productReq.Attributes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	product.Attributes = attributes
+
+	// Create the product and record its initial stock in the ledger
+	err = h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		statusID, err := statusIDByName(tx, statusForStock(product.Stock))
+		if err != nil {
+			return err
+		}
+		product.StatusID = statusID
+
+		initialStock := product.Stock
+		product.Stock = 0
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		if initialStock == 0 {
+			return checkSerialCount(product, 0, productReq.Serials)
+		}
+		_, err = applyStockChange(tx, stockChange{
+			ProductID: product.ID,
+			Delta:     initialStock,
+			Reason:    models.StockReasonAdjustment,
+			UserEmail: c.GetString("email"),
+			Serials:   productReq.Serials,
+			Lot:       lotFromRequest(productReq.Lot, nil),
+		})
+		return err
+	})
+	if errors.Is(err, errSerials) || errors.Is(err, errLots) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"data":    gin.H{},
-			"message": result.Error.Error(),
+			"message": err.Error(),
 		})
 		return
 	}
@@ -284,6 +524,7 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", productETag(product))
 	c.JSON(http.StatusCreated, gin.H{
 		"status": "success",
 		"data": gin.H{
@@ -295,31 +536,239 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 			"description": product.Description,
 			"stock":       product.Stock,
 			"price":       product.Price,
+			"currency":    product.Currency,
+			"serialized":  product.Serialized,
+			"lot_tracked": product.LotTracked,
+			"is_kit":      product.IsKit,
+			"attributes":  product.Attributes,
 			"status":      product.Status,
 			"category":    product.Category,
+			"version":     product.Version,
 		},
 		"message": "Product created successfully",
 	})
 }
 
+// UpdateProduct applies a partial update to the product identified by the path
+// ID. Only the fields present in the body are changed; stock and status are
+// managed through the stock endpoints.
 func (h *ProductHandler) UpdateProduct(c *gin.Context) {
+	var productReq requests.PatchProductRequest
 	var product models.Product
 	ctx := context.Background()
-	if err := c.ShouldBindJSON(&product); err != nil {
+
+	if err := c.ShouldBindJSON(&productReq); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
-	result := h.DB.WithContext(ctx).Updates(&product)
-	if result.Error != nil {
+
+	if err := h.DB.WithContext(ctx).First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
+		})
+		return
+	}
+	if !checkIfMatch(c, product) {
+		return
+	}
+
+	updates := map[string]interface{}{}
+	newPrice, newCurrency := product.Price, product.Currency
+	if productReq.Name != nil {
+		if productNameTaken(h.DB.WithContext(ctx), *productReq.Name, product.ID) {
+			c.JSON(http.StatusConflict, gin.H{
+				"status":  "error",
+				"data":    gin.H{},
+				"message": "A product with this name already exists",
+			})
+			return
+		}
+		updates["name"] = *productReq.Name
+	}
+	if productReq.Brand != nil {
+		updates["brand"] = *productReq.Brand
+	}
+	if productReq.Model2 != nil {
+		updates["model2"] = *productReq.Model2
+	}
+	if productReq.Description != nil {
+		updates["description"] = *productReq.Description
+	}
+	if productReq.Price != nil {
+		price, err := models.ParseMoney(*productReq.Price)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid price value",
+			})
+			return
+		}
+		updates["price_cents"] = price
+		newPrice = price
+	}
+	if productReq.Currency != nil {
+		updates["currency"] = *productReq.Currency
+		newCurrency = *productReq.Currency
+	}
+	if productReq.CategoryID != nil {
+		categoryID, err := strconv.ParseUint(*productReq.CategoryID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid category_id value",
+			})
+			return
+		}
+		var category models.Category
+		if err := h.DB.WithContext(ctx).First(&category, categoryID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Category not found",
+			})
+			return
+		}
+		updates["category_id"] = category.ID
+	}
+
+	// Attributes are checked when they are replaced, and kept values are
+	// checked again when the product moves to another category
+	if productReq.Attributes != nil || productReq.CategoryID != nil {
+		categoryID := product.CategoryID
+		if id, ok := updates["category_id"].(uint); ok {
+			categoryID = id
+		}
+		values := map[string]interface{}(product.Attributes)
+		if productReq.Attributes != nil {
+			values = productReq.Attributes
+		}
+		schema, err := categorySchema(h.DB.WithContext(ctx), categoryID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		attributes, err := validateAttributes(schema, values)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		updates["attributes"] = attributes
+	}
+
+	if productReq.MinStock != nil {
+		updates["min_stock"] = *productReq.MinStock
+	}
+	if productReq.ReorderQuantity != nil {
+		updates["reorder_quantity"] = *productReq.ReorderQuantity
+	}
+	serialized, lotTracked := product.Serialized, product.LotTracked
+	if productReq.Serialized != nil {
+		serialized = *productReq.Serialized
+	}
+	if productReq.LotTracked != nil {
+		lotTracked = *productReq.LotTracked
+	}
+	if serialized != product.Serialized || lotTracked != product.LotTracked {
+		if product.IsKit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "A kit cannot be serialized or lot tracked",
+			})
+			return
+		}
+		// Every unit in stock needs a serial or a lot, so the modes only switch
+		// while there are no units; the version check keeps stock from changing meanwhile
+		if product.Stock != 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"status":  "error",
+				"data":    gin.H{},
+				"message": "Tracking mode can only change while the product has no stock",
+			})
+			return
+		}
+		if err := checkTrackingModes(serialized, lotTracked); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		// A kit sale names no serials, so kit components stay unserialized
+		if serialized && !product.Serialized {
+			var kitCount int64
+			if err := h.DB.WithContext(ctx).Model(&models.KitComponent{}).Where("component_id = ?", product.ID).Count(&kitCount).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"status":  "error",
+					"data":    gin.H{},
+					"message": err.Error(),
+				})
+				return
+			}
+			if kitCount > 0 {
+				c.JSON(http.StatusConflict, gin.H{
+					"status":  "error",
+					"data":    gin.H{},
+					"message": "This product is a component of a kit and cannot be serialized",
+				})
+				return
+			}
+		}
+		updates["serialized"] = serialized
+		updates["lot_tracked"] = lotTracked
+	}
+
+	if len(updates) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No fields to update",
+		})
+		return
+	}
+
+	// Only update the row if nobody changed it since it was read
+	updates["version"] = gorm.Expr("version + 1")
+	var rowsAffected int64
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Product{}).
+			Where("id = ? AND version = ?", product.ID, product.Version).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		rowsAffected = result.RowsAffected
+		if rowsAffected == 0 || (newPrice == product.Price && newCurrency == product.Currency) {
+			return nil
+		}
+
+		change := models.PriceChange{
+			ProductID:   product.ID,
+			OldPrice:    product.Price,
+			OldCurrency: product.Currency,
+			NewPrice:    newPrice,
+			NewCurrency: newCurrency,
+			Source:      models.PriceSourceManual,
+			UserEmail:   c.GetString("email"),
+		}
+		return tx.Create(&change).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"data":    gin.H{},
-			"message": result.Error.Error(),
+			"message": err.Error(),
 		})
 		return
 	}
+
+	if err := h.DB.WithContext(ctx).Preload("Category").Preload("Status").First(&product, product.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if rowsAffected == 0 {
+		respondVersionMismatch(c, product)
+		return
+	}
+
+	c.Header("ETag", productETag(product))
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"data":    product,
@@ -327,21 +776,80 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	})
 }
 
+// Delete soft deletes the product identified by the path ID and releases its
+// active reservations, which could no longer be confirmed
 func (h *ProductHandler) Delete(c *gin.Context) {
 	var product models.Product
 	ctx := context.Background()
-	if err := c.ShouldBindJSON(&product); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
+
+	if err := h.DB.WithContext(ctx).First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
 		})
 		return
 	}
-	result := h.DB.WithContext(ctx).Delete(&product)
-	if result.Error != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+
+	if !checkIfMatch(c, product) {
+		return
+	}
+
+	// Kits read their stock from their components, so a component stays
+	// until it is taken out of every kit
+	var kitCount int64
+	if err := h.DB.WithContext(ctx).Model(&models.KitComponent{}).Where("component_id = ?", product.ID).Count(&kitCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"data":    gin.H{},
-			"message": result.Error.Error(),
+			"message": err.Error(),
+		})
+		return
+	}
+	if kitCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "This product is a component of a kit",
+		})
+		return
+	}
+
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Reservations are locked before the product, in the same order as a confirm
+		if err := tx.Model(&models.Reservation{}).
+			Where("product_id = ? AND status = ?", product.ID, models.ReservationActive).
+			Update("status", models.ReservationReleased).Error; err != nil {
+			return err
+		}
+		result := tx.Where("version = ?", product.Version).Delete(&product)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVersionMismatch
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errVersionMismatch) {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		var current models.Product
+		if err := h.DB.WithContext(ctx).First(&current, product.ID).Error; err == nil {
+			respondVersionMismatch(c, current)
+			return
+		}
+		// Someone else deleted it in the meantime
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
 		})
 		return
 	}
@@ -365,28 +873,282 @@ func (h *ProductHandler) UpdateStock(c *gin.Context) {
 		return
 	}
 
-	// Validar que el stock sea válido
-	if stockRequest.Stock < 0 {
+	var product models.Product
+	if err := h.DB.WithContext(ctx).First(&product, productId).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
+		})
+		return
+	}
+	if !checkIfMatch(c, product) {
+		return
+	}
+
+	reason := stockRequest.Reason
+	if reason == "" {
+		reason = models.StockReasonAdjustment
+	}
+
+	// Actualizar el stock y registrar el movimiento en la misma transacción
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.Product
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			First(&current, product.ID).Error; err != nil {
+			return err
+		}
+		if current.Version != product.Version {
+			return errVersionMismatch
+		}
+
+		// The absolute value is the level of a single warehouse, the default
+		// one unless the request names another
+		warehouseID, err := resolveWarehouseID(tx, stockRequest.WarehouseID)
+		if err != nil {
+			return err
+		}
+		warehouseStock, err := lockWarehouseStock(tx, current.ID, warehouseID)
+		if err != nil {
+			return err
+		}
+
+		delta := *stockRequest.Stock - warehouseStock.Stock
+		if delta == 0 {
+			return checkSerialCount(current, 0, stockRequest.Serials)
+		}
+
+		_, err = applyStockChange(tx, stockChange{
+			ProductID:       current.ID,
+			WarehouseID:     warehouseID,
+			Delta:           delta,
+			Reason:          reason,
+			UserEmail:       c.GetString("email"),
+			Serials:         stockRequest.Serials,
+			Lot:             lotFromRequest(stockRequest.Lot, nil),
+			ExpectedVersion: current.Version,
+		})
+		return err
+	})
+
+	// Verificar si se encontró el producto
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
+		})
+		return
+	}
+	if errors.Is(err, errVersionMismatch) {
+		h.DB.WithContext(ctx).First(&product, product.ID)
+		respondVersionMismatch(c, product)
+		return
+	}
+	if errors.Is(err, errWarehouseNotFound) {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Stock cannot be negative",
+			"error": "Warehouse not found",
+		})
+		return
+	}
+	if errors.Is(err, errKitStock) || errors.Is(err, errInsufficientAvailable) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, errSerials) || errors.Is(err, errLots) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
 		})
 		return
 	}
 
-	// Actualizar el stock en la base de datos
-	result := h.DB.WithContext(ctx).Model(&models.Product{}).Where("id = ?", productId).Update("stock", stockRequest.Stock)
+	if err := h.DB.WithContext(ctx).First(&product, product.ID).Error; err == nil {
+		c.Header("ETag", productETag(product))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    gin.H{},
+		"message": "Product stock updated successfully",
+	})
+}
+
+// GetMovements returns the stock ledger of a product, newest first
+func (h *ProductHandler) GetMovements(c *gin.Context) {
+	productId := c.Param("id")
+	ctx := context.Background()
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var product models.Product
+	if err := h.DB.WithContext(ctx).First(&product, productId).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
+		})
+		return
+	}
 
-	if result.Error != nil {
+	var total int64
+	query := h.DB.WithContext(ctx).Model(&models.StockMovement{}).Where("product_id = ?", product.ID)
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var movements []models.StockMovement
+	if err := query.Order("id DESC").Offset(page.Offset()).Limit(page.Limit).Find(&movements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   movements,
+		"count":  len(movements),
+		"page":   page.Page,
+		"limit":  page.Limit,
+		"total":  total,
+	})
+}
+
+// AdjustStock applies a signed delta to the stock of a product and returns the
+// resulting level
+func (h *ProductHandler) AdjustStock(c *gin.Context) {
+	productId := c.Param("id")
+	var adjustRequest requests.AdjustStockRequest
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&adjustRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var product models.Product
+	if err := h.DB.WithContext(ctx).First(&product, productId).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
+		})
+		return
+	}
+
+	if !checkIfMatch(c, product) {
+		return
+	}
+
+	reason := adjustRequest.Reason
+	if reason == "" {
+		reason = models.StockReasonAdjustment
+	}
+
+	var movement models.StockMovement
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		movement, err = applyStockChange(tx, stockChange{
+			ProductID:       product.ID,
+			WarehouseID:     adjustRequest.WarehouseID,
+			Delta:           adjustRequest.Delta,
+			Reason:          reason,
+			UserEmail:       c.GetString("email"),
+			Serials:         adjustRequest.Serials,
+			Lot:             lotFromRequest(adjustRequest.Lot, nil),
+			ExpectedVersion: product.Version,
+		})
+		return err
+	})
+	// The product was deleted after it was read
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
+		})
+		return
+	}
+	if errors.Is(err, errVersionMismatch) {
+		h.DB.WithContext(ctx).First(&product, product.ID)
+		respondVersionMismatch(c, product)
+		return
+	}
+	if errors.Is(err, errNegativeStock) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Not enough stock for this adjustment",
+		})
+		return
+	}
+	if errors.Is(err, errWarehouseNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Warehouse not found",
+		})
+		return
+	}
+	if errors.Is(err, errKitStock) || errors.Is(err, errInsufficientAvailable) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, errSerials) || errors.Is(err, errLots) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"data":    gin.H{},
-			"message": result.Error.Error(),
+			"message": err.Error(),
 		})
 		return
 	}
 
-	// Verificar si se encontró el producto
-	if result.RowsAffected == 0 {
+	product.Version++
+	c.Header("ETag", productETag(product))
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"product_id":      product.ID,
+			"stock":           movement.StockAfter,
+			"warehouse_id":    movement.WarehouseID,
+			"warehouse_stock": movement.WarehouseStockAfter,
+			"movement":        movement,
+		},
+		"message": "Product stock adjusted successfully",
+	})
+}
+
+// GetStatusChanges returns the status transitions of a product, newest first
+func (h *ProductHandler) GetStatusChanges(c *gin.Context) {
+	productId := c.Param("id")
+	ctx := context.Background()
+
+	var product models.Product
+	if err := h.DB.WithContext(ctx).First(&product, productId).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"status":  "error",
 			"data":    gin.H{},
@@ -395,9 +1157,232 @@ func (h *ProductHandler) UpdateStock(c *gin.Context) {
 		return
 	}
 
+	var changes []models.StatusChange
+	if err := h.DB.WithContext(ctx).
+		Preload("FromStatus").
+		Preload("ToStatus").
+		Where("product_id = ?", product.ID).
+		Order("id DESC").
+		Find(&changes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   changes,
+		"count":  len(changes),
+	})
+}
+
+// ListTrash returns a page of soft deleted products, most recently deleted first
+func (h *ProductHandler) ListTrash(c *gin.Context) {
+	var products []models.Product
+	ctx := context.Background()
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := h.DB.WithContext(ctx).Unscoped().Model(&models.Product{}).Where("deleted_at IS NOT NULL")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.
+		Preload("Category").
+		Preload("Status").
+		Order("deleted_at DESC").
+		Offset(page.Offset()).
+		Limit(page.Limit).
+		Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   products,
+		"count":  len(products),
+		"page":   page.Page,
+		"limit":  page.Limit,
+		"total":  total,
+	})
+}
+
+// findTrashedProduct loads a soft deleted product by the path ID
+func (h *ProductHandler) findTrashedProduct(ctx context.Context, c *gin.Context) (models.Product, bool) {
+	var product models.Product
+	if err := h.DB.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found in trash",
+		})
+		return product, false
+	}
+	return product, true
+}
+
+// Restore brings a soft deleted product back from the trash
+func (h *ProductHandler) Restore(c *gin.Context) {
+	ctx := context.Background()
+
+	product, ok := h.findTrashedProduct(ctx, c)
+	if !ok {
+		return
+	}
+
+	if productNameTaken(h.DB.WithContext(ctx), product.Name, product.ID) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Another product is already using this name",
+		})
+		return
+	}
+
+	var category models.Category
+	if err := h.DB.WithContext(ctx).First(&category, product.CategoryID).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "The category of this product no longer exists",
+		})
+		return
+	}
+
+	// A kit's components may have moved while it was in the trash
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&product).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if !product.IsKit {
+			return nil
+		}
+		return refreshKit(tx, product.ID, models.StockMovement{UserEmail: c.GetString("email")})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.DB.WithContext(ctx).Preload("Category").Preload("Status").First(&product, product.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
-		"data":    gin.H{},
-		"message": "Product stock updated successfully",
+		"data":    product,
+		"message": "Product restored successfully",
+	})
+}
+
+// errProductReferenced is returned when records that must outlive a product
+// still point at it
+var errProductReferenced = errors.New("product is still referenced")
+
+// purgeReferences lists the records that keep a product from being purged.
+// They are business history, so the product stays in the trash instead.
+var purgeReferences = []struct {
+	model   interface{}
+	column  string
+	message string
+}{
+	{&models.PurchaseOrderLine{}, "product_id", "This product appears on purchase orders"},
+	{&models.SalesOrderLine{}, "product_id", "This product appears on sales orders"},
+	{&models.RMA{}, "product_id", "This product appears on returns"},
+	{&models.SerialUnit{}, "product_id", "This product has serialized units on record"},
+	{&models.Lot{}, "product_id", "This product has lots on record"},
+	{&models.StocktakeItem{}, "product_id", "This product appears on stocktakes"},
+	{&models.KitComponent{}, "component_id", "This product is still a component of a kit"},
+}
+
+// Purge permanently deletes a product from the trash along with the records
+// that only matter while it exists. Its stock ledger, price history and
+// confirmed reservations are kept for auditing and still carry the ID of the
+// purged product. Products still referenced by orders or other history cannot
+// be purged.
+func (h *ProductHandler) Purge(c *gin.Context) {
+	ctx := context.Background()
+
+	product, ok := h.findTrashedProduct(ctx, c)
+	if !ok {
+		return
+	}
+
+	var blocked string
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, reference := range purgeReferences {
+			var count int64
+			if err := tx.Unscoped().Model(reference.model).
+				Where(reference.column+" = ?", product.ID).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				blocked = reference.message
+				return errProductReferenced
+			}
+		}
+
+		// Prices that were never applied have nothing left to change
+		if err := tx.Unscoped().Where("product_id = ? AND applied_at IS NULL", product.ID).Delete(&models.ScheduledPrice{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.WarehouseStock{}).Error; err != nil {
+			return err
+		}
+		// Only confirmed reservations stand for a sale
+		if err := tx.Unscoped().Where("product_id = ? AND status <> ?", product.ID, models.ReservationConfirmed).Delete(&models.Reservation{}).Error; err != nil {
+			return err
+		}
+		// Alerts only make sense for a live product, acknowledged or not
+		if err := tx.Unscoped().Where("product_id = ?", product.ID).Delete(&models.LowStockAlert{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("kit_id = ?", product.ID).Delete(&models.KitComponent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.ProductSpec{}).Error; err != nil {
+			return err
+		}
+		// Saved builds are drafts rather than history, so they just lose the part
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.BuildItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&product).Error
+	})
+	if errors.Is(err, errProductReferenced) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": blocked,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    gin.H{"id": product.ID},
+		"message": "Product permanently deleted",
 	})
 }
@@ -0,0 +1,504 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+	"github.com/lumiere11/pc-inventory-go/requests"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// openPurchaseOrderStatuses are the statuses of orders still expecting goods
+var openPurchaseOrderStatuses = []string{
+	models.PurchaseOrderDraft,
+	models.PurchaseOrderSent,
+	models.PurchaseOrderPartiallyReceived,
+}
+
+var (
+	// errPurchaseOrderState is returned when the order status does not allow the operation
+	errPurchaseOrderState = errors.New("purchase order status does not allow this operation")
+	// errPurchaseOrderLine is returned when a received line is not part of the order
+	errPurchaseOrderLine = errors.New("line does not belong to this purchase order")
+	// errOverReceipt is returned when more units arrive than were ordered
+	errOverReceipt = errors.New("received quantity exceeds the ordered quantity")
+	// errLineProductTrashed is returned when units arrive for a product in the trash
+	errLineProductTrashed = errors.New("product is in the trash")
+)
+
+type PurchaseOrderHandler struct {
+	DB *gorm.DB
+}
+
+func NewPurchaseOrderHandler(db *gorm.DB) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{
+		DB: db,
+	}
+}
+
+// List returns purchase orders, optionally filtered by status and supplier
+func (h *PurchaseOrderHandler) List(c *gin.Context) {
+	var orders []models.PurchaseOrder
+	ctx := context.Background()
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := h.DB.WithContext(ctx).Model(&models.PurchaseOrder{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if supplierID := c.Query("supplier_id"); supplierID != "" {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.Preload("Supplier").Preload("Lines").
+		Order("id DESC").Offset(page.Offset()).Limit(page.Limit).Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   orders,
+		"count":  len(orders),
+		"page":   page.Page,
+		"limit":  page.Limit,
+		"total":  total,
+	})
+}
+
+func (h *PurchaseOrderHandler) Get(c *gin.Context) {
+	ctx := context.Background()
+
+	order, ok := h.findOrder(ctx, c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   order,
+	})
+}
+
+// Create opens a draft purchase order
+func (h *PurchaseOrderHandler) Create(c *gin.Context) {
+	var orderReq requests.PurchaseOrderRequest
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&orderReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	lines, err := h.buildLines(ctx, orderReq)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	currency := orderReq.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	order := models.PurchaseOrder{
+		SupplierID: orderReq.SupplierID,
+		Status:     models.PurchaseOrderDraft,
+		Currency:   currency,
+		Notes:      orderReq.Notes,
+		CreatedBy:  c.GetString("email"),
+		Lines:      lines,
+	}
+	if err := h.DB.WithContext(ctx).Create(&order).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.respondOrder(ctx, c, http.StatusCreated, order.ID, "Purchase order created successfully")
+}
+
+// Update replaces the supplier, notes and lines of a draft purchase order
+func (h *PurchaseOrderHandler) Update(c *gin.Context) {
+	var orderReq requests.PurchaseOrderRequest
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&orderReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	order, ok := h.findOrder(ctx, c)
+	if !ok {
+		return
+	}
+
+	lines, err := h.buildLines(ctx, orderReq)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err = h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockOrderInStatus(tx, &order, models.PurchaseOrderDraft); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"supplier_id": orderReq.SupplierID,
+			"notes":       orderReq.Notes,
+		}
+		if orderReq.Currency != "" {
+			updates["currency"] = orderReq.Currency
+		}
+		if err := tx.Model(&order).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("purchase_order_id = ?", order.ID).Delete(&models.PurchaseOrderLine{}).Error; err != nil {
+			return err
+		}
+		for i := range lines {
+			lines[i].PurchaseOrderID = order.ID
+		}
+		return tx.Create(&lines).Error
+	})
+	if h.respondError(c, order, err) {
+		return
+	}
+
+	h.respondOrder(ctx, c, http.StatusOK, order.ID, "Purchase order updated successfully")
+}
+
+// Send marks a draft purchase order as sent to the supplier
+func (h *PurchaseOrderHandler) Send(c *gin.Context) {
+	ctx := context.Background()
+
+	order, ok := h.findOrder(ctx, c)
+	if !ok {
+		return
+	}
+
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockOrderInStatus(tx, &order, models.PurchaseOrderDraft); err != nil {
+			return err
+		}
+		return tx.Model(&order).Updates(map[string]interface{}{
+			"status":  models.PurchaseOrderSent,
+			"sent_at": time.Now(),
+		}).Error
+	})
+	if h.respondError(c, order, err) {
+		return
+	}
+
+	h.respondOrder(ctx, c, http.StatusOK, order.ID, "Purchase order sent successfully")
+}
+
+// Cancel closes a purchase order that has not received any goods yet
+func (h *PurchaseOrderHandler) Cancel(c *gin.Context) {
+	ctx := context.Background()
+
+	order, ok := h.findOrder(ctx, c)
+	if !ok {
+		return
+	}
+
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockOrderInStatus(tx, &order, models.PurchaseOrderDraft, models.PurchaseOrderSent); err != nil {
+			return err
+		}
+		return tx.Model(&order).Update("status", models.PurchaseOrderCancelled).Error
+	})
+	if h.respondError(c, order, err) {
+		return
+	}
+
+	h.respondOrder(ctx, c, http.StatusOK, order.ID, "Purchase order cancelled successfully")
+}
+
+// Receive books the units that arrived for some lines of a sent order. Each
+// line goes through the stock ledger as a purchase, and the order becomes
+// received once every line is complete.
+func (h *PurchaseOrderHandler) Receive(c *gin.Context) {
+	var receiveReq requests.ReceivePurchaseOrderRequest
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&receiveReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	order, ok := h.findOrder(ctx, c)
+	if !ok {
+		return
+	}
+
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockOrderInStatus(tx, &order, models.PurchaseOrderSent, models.PurchaseOrderPartiallyReceived); err != nil {
+			return err
+		}
+
+		var lines []models.PurchaseOrderLine
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Where("purchase_order_id = ?", order.ID).
+			Order("id").
+			Find(&lines).Error; err != nil {
+			return err
+		}
+		byID := make(map[uint]*models.PurchaseOrderLine, len(lines))
+		for i := range lines {
+			byID[lines[i].ID] = &lines[i]
+		}
+
+		for _, received := range receiveReq.Lines {
+			line, ok := byID[received.LineID]
+			if !ok {
+				return fmt.Errorf("%w: %d", errPurchaseOrderLine, received.LineID)
+			}
+			if line.ReceivedQuantity+received.Quantity > line.Quantity {
+				return fmt.Errorf("%w: line %d has %d units pending", errOverReceipt, line.ID, line.Quantity-line.ReceivedQuantity)
+			}
+
+			if _, err := applyStockChange(tx, stockChange{
+				ProductID:     line.ProductID,
+				WarehouseID:   received.WarehouseID,
+				Delta:         received.Quantity,
+				Reason:        models.StockReasonPurchase,
+				UserEmail:     c.GetString("email"),
+				ReferenceType: models.ReferencePurchaseOrderLine,
+				ReferenceID:   line.ID,
+				Serials:       received.Serials,
+				Lot:           lotFromRequest(received.Lot, &order.SupplierID),
+			}); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("%w: line %d", errLineProductTrashed, line.ID)
+				}
+				return err
+			}
+
+			line.ReceivedQuantity += received.Quantity
+			if err := tx.Model(line).Update("received_quantity", line.ReceivedQuantity).Error; err != nil {
+				return err
+			}
+		}
+
+		updates := map[string]interface{}{"status": models.PurchaseOrderReceived, "received_at": time.Now()}
+		for _, line := range lines {
+			if line.ReceivedQuantity < line.Quantity {
+				updates = map[string]interface{}{"status": models.PurchaseOrderPartiallyReceived}
+				break
+			}
+		}
+		return tx.Model(&order).Updates(updates).Error
+	})
+	if h.respondError(c, order, err) {
+		return
+	}
+
+	h.respondOrder(ctx, c, http.StatusOK, order.ID, "Purchase order received successfully")
+}
+
+// ProductOrders returns the open purchase orders that include a product
+func (h *PurchaseOrderHandler) ProductOrders(c *gin.Context) {
+	var product models.Product
+	var orders []models.PurchaseOrder
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
+		})
+		return
+	}
+
+	if err := h.DB.WithContext(ctx).
+		Preload("Supplier").
+		Preload("Lines", "product_id = ?", product.ID).
+		Where("status IN ?", openPurchaseOrderStatuses).
+		Where("id IN (?)", h.DB.Model(&models.PurchaseOrderLine{}).Select("purchase_order_id").Where("product_id = ?", product.ID)).
+		Order("id").
+		Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var pending int32
+	for _, order := range orders {
+		for _, line := range order.Lines {
+			pending += line.Quantity - line.ReceivedQuantity
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"product_id":      product.ID,
+			"pending_units":   pending,
+			"purchase_orders": orders,
+		},
+		"count": len(orders),
+	})
+}
+
+// buildLines validates the supplier and products of a request and turns its
+// lines into models
+func (h *PurchaseOrderHandler) buildLines(ctx context.Context, orderReq requests.PurchaseOrderRequest) ([]models.PurchaseOrderLine, error) {
+	var supplier models.Supplier
+	if err := h.DB.WithContext(ctx).First(&supplier, orderReq.SupplierID).Error; err != nil {
+		return nil, errors.New("supplier not found")
+	}
+
+	lines := make([]models.PurchaseOrderLine, 0, len(orderReq.Lines))
+	seen := make(map[uint]bool, len(orderReq.Lines))
+	for _, lineReq := range orderReq.Lines {
+		if seen[lineReq.ProductID] {
+			return nil, fmt.Errorf("product %d appears in more than one line", lineReq.ProductID)
+		}
+		seen[lineReq.ProductID] = true
+
+		var product models.Product
+		if err := h.DB.WithContext(ctx).Select("id", "is_kit").First(&product, lineReq.ProductID).Error; err != nil {
+			return nil, fmt.Errorf("product %d not found", lineReq.ProductID)
+		}
+		if product.IsKit {
+			return nil, fmt.Errorf("product %d is a kit; order its components instead", lineReq.ProductID)
+		}
+
+		cost, err := models.ParseMoney(lineReq.UnitCost)
+		if err != nil {
+			return nil, fmt.Errorf("unit_cost of product %d: %w", lineReq.ProductID, err)
+		}
+
+		lines = append(lines, models.PurchaseOrderLine{
+			ProductID: lineReq.ProductID,
+			Quantity:  lineReq.Quantity,
+			UnitCost:  cost,
+		})
+	}
+	return lines, nil
+}
+
+// findOrder loads the purchase order identified by the path ID, answering 404
+// when it does not exist
+func (h *PurchaseOrderHandler) findOrder(ctx context.Context, c *gin.Context) (models.PurchaseOrder, bool) {
+	var order models.PurchaseOrder
+	if err := h.DB.WithContext(ctx).
+		Preload("Supplier").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Lines.Product").
+		First(&order, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Purchase order not found",
+		})
+		return order, false
+	}
+	return order, true
+}
+
+// lockOrderInStatus reloads a purchase order under a row lock and checks that
+// it is in one of the given statuses
+func lockOrderInStatus(tx *gorm.DB, order *models.PurchaseOrder, statuses ...string) error {
+	if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		First(order, order.ID).Error; err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		if order.Status == status {
+			return nil
+		}
+	}
+	return errPurchaseOrderState
+}
+
+// respondError writes the response for a failed purchase order operation and
+// reports whether it did
+func (h *PurchaseOrderHandler) respondError(c *gin.Context, order models.PurchaseOrder, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, errPurchaseOrderState):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Purchase order is " + order.Status,
+		})
+	case errors.Is(err, errLineProductTrashed):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+	case errors.Is(err, errPurchaseOrderLine), errors.Is(err, errOverReceipt), errors.Is(err, errSerials), errors.Is(err, errLots):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, errWarehouseNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Warehouse not found",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+	}
+	return true
+}
+
+// respondOrder reloads a purchase order with its lines and writes it
+func (h *PurchaseOrderHandler) respondOrder(ctx context.Context, c *gin.Context, code int, id uint, message string) {
+	var order models.PurchaseOrder
+	if err := h.DB.WithContext(ctx).
+		Preload("Supplier").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Lines.Product").
+		First(&order, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(code, gin.H{
+		"status":  "success",
+		"data":    order,
+		"message": message,
+	})
+}
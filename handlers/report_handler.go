@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+	"gorm.io/gorm"
+)
+
+type ReportHandler struct {
+	DB *gorm.DB
+}
+
+func NewReportHandler(db *gorm.DB) *ReportHandler {
+	return &ReportHandler{
+		DB: db,
+	}
+}
+
+// lowStockRow is a product below its minimum stock along with the quantity to reorder
+type lowStockRow struct {
+	ProductID       uint   `json:"product_id"`
+	Name            string `json:"name"`
+	CategoryID      uint   `json:"category_id"`
+	CategoryName    string `json:"category_name"`
+	Stock           int32  `json:"stock"`
+	MinStock        int32  `json:"min_stock"`
+	ReorderQuantity int32  `json:"reorder_quantity"`
+}
+
+// LowStock lists every product whose stock is below its own minimum or, when
+// it has none, the default minimum of its category
+func (h *ReportHandler) LowStock(c *gin.Context) {
+	var rows []lowStockRow
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).Model(&models.Product{}).
+		Select(`products.id AS product_id, products.name, products.category_id, categories.name AS category_name, products.stock,
+			COALESCE(products.min_stock, categories.default_min_stock) AS min_stock,
+			COALESCE(products.reorder_quantity, categories.default_reorder_quantity, 0) AS reorder_quantity`).
+		Joins("JOIN categories ON categories.id = products.category_id").
+		Where("products.stock < COALESCE(products.min_stock, categories.default_min_stock)").
+		Order("products.stock - COALESCE(products.min_stock, categories.default_min_stock)").
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   rows,
+		"count":  len(rows),
+	})
+}
+
+// defaultWarrantyWindowDays is the window of the warranty report when no days are given
+const defaultWarrantyWindowDays = 30
+
+// warrantyExpiringRow is a lot with units in stock whose supplier warranty ends soon
+type warrantyExpiringRow struct {
+	LotID             uint      `json:"lot_id"`
+	LotNumber         string    `json:"lot_number"`
+	ProductID         uint      `json:"product_id"`
+	ProductName       string    `json:"product_name"`
+	WarehouseID       uint      `json:"warehouse_id"`
+	WarehouseName     string    `json:"warehouse_name"`
+	SupplierID        *uint     `json:"supplier_id"`
+	SupplierReference string    `json:"supplier_reference"`
+	ReceivedAt        time.Time `json:"received_at"`
+	WarrantyExpiresAt time.Time `json:"warranty_expires_at"`
+	Remaining         int32     `json:"remaining"`
+}
+
+// WarrantyExpiring lists the stock held in lots whose supplier warranty
+// expires within the next days, soonest first
+func (h *ReportHandler) WarrantyExpiring(c *gin.Context) {
+	var rows []warrantyExpiringRow
+	ctx := context.Background()
+
+	days := defaultWarrantyWindowDays
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 || parsed > 3650 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a number between 0 and 3650"})
+			return
+		}
+		days = parsed
+	}
+
+	now := time.Now()
+	if err := h.DB.WithContext(ctx).Model(&models.Lot{}).
+		Select(`lots.id AS lot_id, lots.lot_number, lots.product_id, products.name AS product_name,
+			lots.warehouse_id, warehouses.name AS warehouse_name, lots.supplier_id, lots.supplier_reference,
+			lots.received_at, lots.warranty_expires_at, lots.remaining`).
+		Joins("JOIN products ON products.id = lots.product_id AND products.deleted_at IS NULL").
+		Joins("JOIN warehouses ON warehouses.id = lots.warehouse_id").
+		Where("lots.remaining > 0 AND lots.warranty_expires_at BETWEEN ? AND ?", now, now.AddDate(0, 0, days)).
+		Order("lots.warranty_expires_at").
+		Order("lots.id").
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var units int32
+	for _, row := range rows {
+		units += row.Remaining
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   rows,
+		"count":  len(rows),
+		"days":   days,
+		"units":  units,
+	})
+}
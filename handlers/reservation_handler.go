@@ -0,0 +1,428 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+	"github.com/lumiere11/pc-inventory-go/requests"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultReservationTTL is how long units are held when the request sets no TTL
+const defaultReservationTTL = 15 * time.Minute
+
+var (
+	// errInsufficientAvailable is returned when the stock not already reserved is too low
+	errInsufficientAvailable = errors.New("not enough available stock")
+	// errReservationClosed is returned when a reservation is no longer active
+	errReservationClosed = errors.New("reservation is no longer active")
+)
+
+type ReservationHandler struct {
+	DB *gorm.DB
+}
+
+func NewReservationHandler(db *gorm.DB) *ReservationHandler {
+	return &ReservationHandler{
+		DB: db,
+	}
+}
+
+// reservedQuantities returns the units held by active, unexpired reservations
+// for each of the given products. A reservation on a kit holds units of each
+// of its components, which count towards the components as well.
+func reservedQuantities(db *gorm.DB, productIDs ...uint) (map[uint]int32, error) {
+	reserved := make(map[uint]int32, len(productIDs))
+	if len(productIDs) == 0 {
+		return reserved, nil
+	}
+
+	var rows []struct {
+		ProductID uint
+		Total     int32
+	}
+	if err := db.Model(&models.Reservation{}).
+		Select("product_id, SUM(quantity) AS total").
+		Where("product_id IN ? AND status = ? AND expires_at > ?", productIDs, models.ReservationActive, time.Now()).
+		Group("product_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		reserved[row.ProductID] = row.Total
+	}
+
+	var held []struct {
+		ProductID uint
+		Total     int32
+	}
+	if err := db.Table("reservations").
+		Select("kit_components.component_id AS product_id, SUM(reservations.quantity * kit_components.quantity) AS total").
+		Joins("JOIN kit_components ON kit_components.kit_id = reservations.product_id").
+		Where("kit_components.component_id IN ? AND reservations.status = ? AND reservations.expires_at > ? AND reservations.deleted_at IS NULL",
+			productIDs, models.ReservationActive, time.Now()).
+		Group("kit_components.component_id").
+		Scan(&held).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range held {
+		reserved[row.ProductID] += row.Total
+	}
+	return reserved, nil
+}
+
+// fillAvailability sets the Available field of each product to its stock minus
+// the units held by reservations. A kit is available as many times as the
+// available units of its components allow; the kits already reserved are held
+// on those components.
+func fillAvailability(db *gorm.DB, products []models.Product) error {
+	ids := make([]uint, 0, len(products))
+	var kitIDs []uint
+	for _, product := range products {
+		ids = append(ids, product.ID)
+		if product.IsKit {
+			kitIDs = append(kitIDs, product.ID)
+		}
+	}
+
+	components, err := kitComponentsByKit(db, kitIDs...)
+	if err != nil {
+		return err
+	}
+	var componentIDs []uint
+	for _, kitComponents := range components {
+		for _, component := range kitComponents {
+			componentIDs = append(componentIDs, component.ComponentID)
+		}
+	}
+
+	reserved, err := reservedQuantities(db, append(ids, componentIDs...)...)
+	if err != nil {
+		return err
+	}
+
+	componentAvailable := make(map[uint]int32, len(componentIDs))
+	if len(componentIDs) > 0 {
+		var componentProducts []models.Product
+		if err := db.Select("id", "stock").Where("id IN ?", componentIDs).Find(&componentProducts).Error; err != nil {
+			return err
+		}
+		for _, component := range componentProducts {
+			componentAvailable[component.ID] = component.Stock - reserved[component.ID]
+		}
+	}
+
+	for i := range products {
+		if products[i].IsKit {
+			products[i].Available = buildableKits(components[products[i].ID], componentAvailable)
+		} else {
+			products[i].Available = products[i].Stock - reserved[products[i].ID]
+		}
+		if products[i].Available < 0 {
+			products[i].Available = 0
+		}
+	}
+	return nil
+}
+
+// List returns the reservations of the current user, or every reservation for
+// admins, optionally filtered by status
+func (h *ReservationHandler) List(c *gin.Context) {
+	var reservations []models.Reservation
+	ctx := context.Background()
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := h.DB.WithContext(ctx).Model(&models.Reservation{})
+	if c.GetString("role") != "admin" {
+		query = query.Where("user_email = ?", c.GetString("email"))
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.Order("id DESC").Offset(page.Offset()).Limit(page.Limit).Find(&reservations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   reservations,
+		"count":  len(reservations),
+		"page":   page.Page,
+		"limit":  page.Limit,
+		"total":  total,
+	})
+}
+
+// Create holds units of a product for the requested TTL
+func (h *ReservationHandler) Create(c *gin.Context) {
+	var reservationReq requests.ReservationRequest
+	var product models.Product
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&reservationReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.DB.WithContext(ctx).First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
+		})
+		return
+	}
+
+	ttl := defaultReservationTTL
+	if reservationReq.TTLSeconds > 0 {
+		ttl = time.Duration(reservationReq.TTLSeconds) * time.Second
+	}
+
+	reservation := models.Reservation{
+		ProductID: product.ID,
+		Quantity:  reservationReq.Quantity,
+		Status:    models.ReservationActive,
+		ExpiresAt: time.Now().Add(ttl),
+		UserEmail: c.GetString("email"),
+	}
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// A kit holds units of its components, so they are locked and checked
+		// along with it
+		components, err := kitComponentsByKit(tx, product.ID)
+		if err != nil {
+			return err
+		}
+		ids := []uint{product.ID}
+		for _, component := range components[product.ID] {
+			ids = append(ids, component.ComponentID)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		// Lock the products in ID order so concurrent reservations see each other
+		var locked []models.Product
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Where("id IN ?", ids).
+			Order("id").
+			Find(&locked).Error; err != nil {
+			return err
+		}
+		if err := fillAvailability(tx, locked); err != nil {
+			return err
+		}
+		for _, candidate := range locked {
+			if candidate.ID == product.ID && candidate.Available < reservation.Quantity {
+				return errInsufficientAvailable
+			}
+		}
+		return tx.Create(&reservation).Error
+	})
+	if errors.Is(err, errInsufficientAvailable) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Not enough available stock to reserve",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"data":    reservation,
+		"message": "Stock reserved successfully",
+	})
+}
+
+// Confirm turns an active reservation into a sale, taking the units out of stock
+func (h *ReservationHandler) Confirm(c *gin.Context) {
+	var confirmReq requests.ConfirmReservationRequest
+	ctx := context.Background()
+
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&confirmReq); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
+
+	reservation, ok := h.findReservation(ctx, c)
+	if !ok {
+		return
+	}
+
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockActiveReservation(tx, &reservation); err != nil {
+			return err
+		}
+
+		// Close the reservation first so the units it holds are free for the
+		// stock change that takes them out
+		reservation.Status = models.ReservationConfirmed
+		if err := tx.Model(&reservation).Update("status", reservation.Status).Error; err != nil {
+			return err
+		}
+
+		movements, err := applyProductChange(tx, stockChange{
+			ProductID:     reservation.ProductID,
+			WarehouseID:   confirmReq.WarehouseID,
+			Delta:         -reservation.Quantity,
+			Reason:        models.StockReasonSale,
+			UserEmail:     c.GetString("email"),
+			ReferenceType: models.ReferenceReservation,
+			ReferenceID:   reservation.ID,
+			Serials:       confirmReq.Serials,
+		})
+		if err != nil {
+			reservation.Status = models.ReservationActive
+			return err
+		}
+
+		// A kit takes out each component, the first movement stands for the sale
+		reservation.StockMovementID = &movements[0].ID
+		return tx.Model(&reservation).Update("stock_movement_id", movements[0].ID).Error
+	})
+	h.respond(c, reservation, err, "Reservation confirmed")
+}
+
+// Release gives the held units back before the reservation expires
+func (h *ReservationHandler) Release(c *gin.Context) {
+	ctx := context.Background()
+
+	reservation, ok := h.findReservation(ctx, c)
+	if !ok {
+		return
+	}
+
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockActiveReservation(tx, &reservation); err != nil {
+			return err
+		}
+		reservation.Status = models.ReservationReleased
+		return tx.Model(&reservation).Update("status", reservation.Status).Error
+	})
+	h.respond(c, reservation, err, "Reservation released")
+}
+
+// findReservation loads the reservation identified by the path ID. Users other
+// than admins can only act on their own reservations.
+func (h *ReservationHandler) findReservation(ctx context.Context, c *gin.Context) (models.Reservation, bool) {
+	var reservation models.Reservation
+	if err := h.DB.WithContext(ctx).First(&reservation, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Reservation not found",
+		})
+		return reservation, false
+	}
+
+	if c.GetString("role") != "admin" && reservation.UserEmail != c.GetString("email") {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "This reservation belongs to another user",
+		})
+		return reservation, false
+	}
+	return reservation, true
+}
+
+// lockActiveReservation reloads a reservation under a row lock and checks that
+// it can still be confirmed or released
+func lockActiveReservation(tx *gorm.DB, reservation *models.Reservation) error {
+	if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		First(reservation, reservation.ID).Error; err != nil {
+		return err
+	}
+	if reservation.Status != models.ReservationActive {
+		return errReservationClosed
+	}
+	if !reservation.ExpiresAt.After(time.Now()) {
+		// The sweeper will store the new status, report it right away
+		reservation.Status = models.ReservationExpired
+		return errReservationClosed
+	}
+	return nil
+}
+
+// respond writes the outcome of a confirm or release
+func (h *ReservationHandler) respond(c *gin.Context, reservation models.Reservation, err error, message string) {
+	switch {
+	case errors.Is(err, errReservationClosed):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    reservation,
+			"message": "Reservation is " + reservation.Status,
+		})
+	case errors.Is(err, errNegativeStock):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    reservation,
+			"message": "Not enough stock left to confirm this reservation",
+		})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// The product went to the trash after the reservation was read
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    reservation,
+			"message": "Product is in the trash",
+		})
+	case errors.Is(err, errKitStock), errors.Is(err, errInsufficientAvailable):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    reservation,
+			"message": err.Error(),
+		})
+	case errors.Is(err, errWarehouseNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Warehouse not found",
+		})
+	case errors.Is(err, errSerials):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"data":    reservation,
+			"message": message,
+		})
+	}
+}
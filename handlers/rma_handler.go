@@ -0,0 +1,431 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+	"github.com/lumiere11/pc-inventory-go/requests"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// rmaTransitions lists the statuses each RMA status can move to
+var rmaTransitions = map[string][]string{
+	models.RMARequested: {models.RMAReceived},
+	models.RMAReceived:  {models.RMAInspected},
+	models.RMAInspected: {models.RMARestocked, models.RMAScrapped},
+}
+
+// openRMAStatuses are the statuses of returns still being processed
+var openRMAStatuses = []string{models.RMARequested, models.RMAReceived, models.RMAInspected}
+
+var (
+	// errRMATransition is returned when an RMA cannot move to the requested status
+	errRMATransition = errors.New("invalid RMA status transition")
+	// errReturnExceedsSold is returned when more units are returned than an order line sold
+	errReturnExceedsSold = errors.New("return quantity exceeds the units sold")
+	// errRMAOrderLine is returned when the order line is missing or belongs to someone else
+	errRMAOrderLine = errors.New("order line not found")
+	// errRMAProduct is returned when the product given does not match the order line
+	errRMAProduct = errors.New("product_id does not match the order line")
+)
+
+type RMAHandler struct {
+	DB *gorm.DB
+}
+
+func NewRMAHandler(db *gorm.DB) *RMAHandler {
+	return &RMAHandler{
+		DB: db,
+	}
+}
+
+// List returns the RMAs of the current user, or every RMA for admins,
+// optionally filtered by status
+func (h *RMAHandler) List(c *gin.Context) {
+	var rmas []models.RMA
+	ctx := context.Background()
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := h.DB.WithContext(ctx).Model(&models.RMA{})
+	if c.GetString("role") != "admin" {
+		query = query.Where("user_email = ?", c.GetString("email"))
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.Order("id DESC").Offset(page.Offset()).Limit(page.Limit).Find(&rmas).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   rmas,
+		"count":  len(rmas),
+		"page":   page.Page,
+		"limit":  page.Limit,
+		"total":  total,
+	})
+}
+
+func (h *RMAHandler) Get(c *gin.Context) {
+	ctx := context.Background()
+
+	rma, ok := h.findRMA(ctx, c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   rma,
+	})
+}
+
+// Create opens a return. Customers return units of an order line they placed;
+// admins can also open a return against a product alone.
+func (h *RMAHandler) Create(c *gin.Context) {
+	var rmaReq requests.RMARequest
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&rmaReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	isAdmin := c.GetString("role") == "admin"
+	if rmaReq.SalesOrderLineID == 0 && !isAdmin {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "sales_order_line_id is required",
+		})
+		return
+	}
+
+	rma := models.RMA{
+		ProductID: rmaReq.ProductID,
+		Quantity:  rmaReq.Quantity,
+		Status:    models.RMARequested,
+		Reason:    rmaReq.Reason,
+		UserEmail: c.GetString("email"),
+	}
+
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if rmaReq.SalesOrderLineID == 0 {
+			var product models.Product
+			if err := tx.Select("id", "serialized").First(&product, rmaReq.ProductID).Error; err != nil {
+				return fmt.Errorf("%w: %d", errOrderProduct, rmaReq.ProductID)
+			}
+			if err := tx.Create(&rma).Error; err != nil {
+				return err
+			}
+			return flagReturnedSerials(tx, product, rma, rmaReq.Serials)
+		}
+
+		// Lock the line so concurrent returns cannot exceed the units sold
+		var line models.SalesOrderLine
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			First(&line, rmaReq.SalesOrderLineID).Error; err != nil {
+			return errRMAOrderLine
+		}
+		var order models.SalesOrder
+		if err := tx.First(&order, line.SalesOrderID).Error; err != nil {
+			return err
+		}
+		if !isAdmin && order.UserEmail != c.GetString("email") {
+			return errRMAOrderLine
+		}
+		if rmaReq.ProductID != 0 && rmaReq.ProductID != line.ProductID {
+			return errRMAProduct
+		}
+
+		var returned int64
+		if err := tx.Model(&models.RMA{}).
+			Where("sales_order_line_id = ?", line.ID).
+			Select("COALESCE(SUM(quantity), 0)").
+			Scan(&returned).Error; err != nil {
+			return err
+		}
+		if int64(line.Quantity)-returned < int64(rma.Quantity) {
+			return fmt.Errorf("%w: %d of %d units already returned", errReturnExceedsSold, returned, line.Quantity)
+		}
+
+		var product models.Product
+		if err := tx.Select("id", "serialized").First(&product, line.ProductID).Error; err != nil {
+			return err
+		}
+		if product.Serialized {
+			// Only units sold on this line can come back against it
+			var sold int64
+			if err := tx.Model(&models.SerialUnit{}).
+				Where("product_id = ? AND serial_number IN ? AND sales_order_line_id = ?", product.ID, rmaReq.Serials, line.ID).
+				Count(&sold).Error; err != nil {
+				return err
+			}
+			if sold != int64(len(rmaReq.Serials)) {
+				return fmt.Errorf("%w: not every serial was sold on this order line", errSerials)
+			}
+		}
+
+		rma.ProductID = line.ProductID
+		rma.SalesOrderLineID = &line.ID
+		if err := tx.Create(&rma).Error; err != nil {
+			return err
+		}
+		return flagReturnedSerials(tx, product, rma, rmaReq.Serials)
+	})
+	if errors.Is(err, errReturnExceedsSold) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, errOrderProduct) || errors.Is(err, errRMAOrderLine) ||
+		errors.Is(err, errRMAProduct) || errors.Is(err, errSerials) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"data":    rma,
+		"message": "RMA created successfully",
+	})
+}
+
+// UpdateStatus moves an RMA to its next status. Restocking puts the units
+// back in stock as a return movement; scrapping leaves the stock untouched.
+func (h *RMAHandler) UpdateStatus(c *gin.Context) {
+	var statusReq requests.RMAStatusRequest
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&statusReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	rma, ok := h.findRMA(ctx, c)
+	if !ok {
+		return
+	}
+
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			First(&rma, rma.ID).Error; err != nil {
+			return err
+		}
+		if !rmaCanMove(rma.Status, statusReq.Status) {
+			return errRMATransition
+		}
+
+		updates := map[string]interface{}{"status": statusReq.Status}
+		if statusReq.Notes != "" {
+			rma.InspectionNotes = statusReq.Notes
+			updates["inspection_notes"] = statusReq.Notes
+		}
+
+		var serials []string
+		if err := tx.Model(&models.SerialUnit{}).
+			Where("rma_id = ?", rma.ID).
+			Order("id").
+			Pluck("serial_number", &serials).Error; err != nil {
+			return err
+		}
+
+		switch statusReq.Status {
+		case models.RMAReceived:
+			if len(serials) > 0 {
+				if err := updateSerialUnits(tx, rma.ProductID, serials, map[string]interface{}{"status": models.SerialReturned}, models.SerialRMA); err != nil {
+					return err
+				}
+			}
+		case models.RMARestocked:
+			movements, err := applyProductChange(tx, stockChange{
+				ProductID:     rma.ProductID,
+				WarehouseID:   statusReq.WarehouseID,
+				Delta:         rma.Quantity,
+				Reason:        models.StockReasonReturn,
+				UserEmail:     c.GetString("email"),
+				ReferenceType: models.ReferenceRMA,
+				ReferenceID:   rma.ID,
+				Serials:       serials,
+			})
+			if err != nil {
+				return err
+			}
+			rma.StockMovementID = &movements[0].ID
+			updates["stock_movement_id"] = movements[0].ID
+		case models.RMAScrapped:
+			if len(serials) > 0 {
+				if err := updateSerialUnits(tx, rma.ProductID, serials, map[string]interface{}{"status": models.SerialWrittenOff}, models.SerialReturned); err != nil {
+					return err
+				}
+			}
+		}
+
+		rma.Status = statusReq.Status
+		return tx.Model(&rma).Updates(updates).Error
+	})
+	if errors.Is(err, errRMATransition) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    rma,
+			"message": fmt.Sprintf("RMA cannot move from %s to %s", rma.Status, statusReq.Status),
+		})
+		return
+	}
+	if errors.Is(err, errWarehouseNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Warehouse not found",
+		})
+		return
+	}
+	if errors.Is(err, errSerials) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, errKitStock) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    rma,
+		"message": "RMA " + rma.Status,
+	})
+}
+
+// ProductRMAs returns the open returns of a product, to spot defective batches
+func (h *RMAHandler) ProductRMAs(c *gin.Context) {
+	var product models.Product
+	var rmas []models.RMA
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
+		})
+		return
+	}
+
+	if err := h.DB.WithContext(ctx).
+		Where("product_id = ? AND status IN ?", product.ID, openRMAStatuses).
+		Order("id").
+		Find(&rmas).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var units int32
+	for _, rma := range rmas {
+		units += rma.Quantity
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"product_id": product.ID,
+			"open_units": units,
+			"rmas":       rmas,
+		},
+		"count": len(rmas),
+	})
+}
+
+// findRMA loads the RMA identified by the path ID. Users other than admins can
+// only see their own returns.
+func (h *RMAHandler) findRMA(ctx context.Context, c *gin.Context) (models.RMA, bool) {
+	var rma models.RMA
+	if err := h.DB.WithContext(ctx).Preload("Product").First(&rma, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "RMA not found",
+		})
+		return rma, false
+	}
+
+	if c.GetString("role") != "admin" && rma.UserEmail != c.GetString("email") {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "This RMA belongs to another user",
+		})
+		return rma, false
+	}
+	return rma, true
+}
+
+// flagReturnedSerials checks the serials of a new RMA on a serialized product
+// and marks the units as under RMA
+func flagReturnedSerials(tx *gorm.DB, product models.Product, rma models.RMA, serials []string) error {
+	if err := checkSerialCount(product, rma.Quantity, serials); err != nil {
+		return err
+	}
+	if len(serials) == 0 {
+		return nil
+	}
+	return updateSerialUnits(tx, product.ID, serials, map[string]interface{}{
+		"status": models.SerialRMA,
+		"rma_id": rma.ID,
+	}, models.SerialSold)
+}
+
+// rmaCanMove reports whether an RMA in the from status can move to the to status
+func rmaCanMove(from, to string) bool {
+	for _, next := range rmaTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+	"github.com/lumiere11/pc-inventory-go/requests"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errOrderProduct is returned when an order line names a missing product
+var errOrderProduct = errors.New("product not found")
+
+type SalesOrderHandler struct {
+	DB *gorm.DB
+}
+
+func NewSalesOrderHandler(db *gorm.DB) *SalesOrderHandler {
+	return &SalesOrderHandler{
+		DB: db,
+	}
+}
+
+// List returns the orders of the current user, or every order for admins
+func (h *SalesOrderHandler) List(c *gin.Context) {
+	var orders []models.SalesOrder
+	ctx := context.Background()
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := h.DB.WithContext(ctx).Model(&models.SalesOrder{})
+	if c.GetString("role") != "admin" {
+		query = query.Where("user_email = ?", c.GetString("email"))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.Preload("Lines").Order("id DESC").Offset(page.Offset()).Limit(page.Limit).Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   orders,
+		"count":  len(orders),
+		"page":   page.Page,
+		"limit":  page.Limit,
+		"total":  total,
+	})
+}
+
+func (h *SalesOrderHandler) Get(c *gin.Context) {
+	var order models.SalesOrder
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Lines.Product").
+		First(&order, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Order not found",
+		})
+		return
+	}
+
+	if c.GetString("role") != "admin" && order.UserEmail != c.GetString("email") {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "This order belongs to another user",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   order,
+	})
+}
+
+// Create places an order for several products at once. The products are
+// locked in ID order, every line is checked against the stock not held by
+// reservations, and all lines are taken out of stock in the same transaction,
+// so either the whole order goes through or nothing changes. Kit lines take
+// their units from the kit's components.
+func (h *SalesOrderHandler) Create(c *gin.Context) {
+	var orderReq requests.SalesOrderRequest
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&orderReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	productIDs := make([]uint, 0, len(orderReq.Lines))
+	seen := make(map[uint]bool, len(orderReq.Lines))
+	for _, lineReq := range orderReq.Lines {
+		if seen[lineReq.ProductID] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("product %d appears in more than one line", lineReq.ProductID),
+			})
+			return
+		}
+		seen[lineReq.ProductID] = true
+		productIDs = append(productIDs, lineReq.ProductID)
+	}
+	currency := orderReq.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	order := models.SalesOrder{
+		UserEmail: c.GetString("email"),
+		Status:    models.SalesOrderCompleted,
+		Currency:  currency,
+	}
+
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Kits take their units from their components, which are locked as well
+		components, err := kitComponentsByKit(tx, productIDs...)
+		if err != nil {
+			return err
+		}
+		lockIDs := append([]uint{}, productIDs...)
+		for _, kitComponents := range components {
+			for _, component := range kitComponents {
+				if !seen[component.ComponentID] {
+					seen[component.ComponentID] = true
+					lockIDs = append(lockIDs, component.ComponentID)
+				}
+			}
+		}
+		// Always lock in the same order so concurrent orders cannot deadlock
+		sort.Slice(lockIDs, func(i, j int) bool { return lockIDs[i] < lockIDs[j] })
+
+		var products []models.Product
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Where("id IN ?", lockIDs).
+			Order("id").
+			Find(&products).Error; err != nil {
+			return err
+		}
+		byID := make(map[uint]models.Product, len(products))
+		for _, product := range products {
+			byID[product.ID] = product
+		}
+
+		reserved, err := reservedQuantities(tx, lockIDs...)
+		if err != nil {
+			return err
+		}
+
+		// Add up what every line needs from each product, so a kit and one of
+		// its components in the same order are checked together
+		demand := make(map[uint]int32, len(lockIDs))
+		now := time.Now()
+		for _, lineReq := range orderReq.Lines {
+			product, ok := byID[lineReq.ProductID]
+			if !ok {
+				return fmt.Errorf("%w: %d", errOrderProduct, lineReq.ProductID)
+			}
+			if product.IsKit {
+				if len(components[product.ID]) == 0 {
+					return fmt.Errorf("%w: kit %d has no components", errKitStock, product.ID)
+				}
+				if available := product.Stock - reserved[product.ID]; available < lineReq.Quantity {
+					return fmt.Errorf("%w: kit %d has %d units available", errInsufficientAvailable, product.ID, available)
+				}
+				for _, component := range components[product.ID] {
+					demand[component.ComponentID] += lineReq.Quantity * component.Quantity
+				}
+			} else {
+				demand[product.ID] += lineReq.Quantity
+			}
+			if err := checkSerialCount(product, lineReq.Quantity, lineReq.Serials); err != nil {
+				return err
+			}
+
+			rate, exchangeRate, err := findExchangeRate(tx, product.Currency, currency, now)
+			if err != nil {
+				return err
+			}
+			line := models.SalesOrderLine{
+				ProductID:       product.ID,
+				WarehouseID:     lineReq.WarehouseID,
+				Quantity:        lineReq.Quantity,
+				ProductPrice:    product.Price,
+				ProductCurrency: product.Currency,
+				UnitPrice:       convertMoney(product.Price, rate),
+			}
+			if exchangeRate != nil {
+				line.ExchangeRateID = &exchangeRate.ID
+			}
+			line.LineTotal = line.UnitPrice * models.Money(line.Quantity)
+			order.Total += line.LineTotal
+			order.Lines = append(order.Lines, line)
+		}
+
+		for _, id := range lockIDs {
+			quantity, needed := demand[id]
+			if !needed {
+				continue
+			}
+			product, ok := byID[id]
+			if !ok {
+				return fmt.Errorf("%w: %d", errOrderProduct, id)
+			}
+			if available := product.Stock - reserved[id]; available < quantity {
+				return fmt.Errorf("%w: product %d has %d units available", errInsufficientAvailable, id, available)
+			}
+		}
+
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+
+		for i := range order.Lines {
+			line := &order.Lines[i]
+			movements, err := applyProductChange(tx, stockChange{
+				ProductID:     line.ProductID,
+				WarehouseID:   line.WarehouseID,
+				Delta:         -line.Quantity,
+				Reason:        models.StockReasonSale,
+				UserEmail:     order.UserEmail,
+				ReferenceType: models.ReferenceSalesOrderLine,
+				ReferenceID:   line.ID,
+				Serials:       orderReq.Lines[i].Serials,
+			})
+			if err != nil {
+				return fmt.Errorf("product %d: %w", line.ProductID, err)
+			}
+
+			// A kit line has one movement per component, all referencing the line
+			line.WarehouseID = movements[0].WarehouseID
+			line.StockMovementID = &movements[0].ID
+			if err := tx.Model(line).Updates(map[string]interface{}{
+				"warehouse_id":      movements[0].WarehouseID,
+				"stock_movement_id": movements[0].ID,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errOrderProduct), errors.Is(err, errNoExchangeRate), errors.Is(err, errWarehouseNotFound), errors.Is(err, errSerials):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	case errors.Is(err, errInsufficientAvailable), errors.Is(err, errNegativeStock), errors.Is(err, errKitStock):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"data":    order,
+		"message": "Order created successfully",
+	})
+}
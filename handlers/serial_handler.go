@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+	"gorm.io/gorm"
+)
+
+type SerialHandler struct {
+	DB *gorm.DB
+}
+
+func NewSerialHandler(db *gorm.DB) *SerialHandler {
+	return &SerialHandler{
+		DB: db,
+	}
+}
+
+// ProductSerials returns the units of a serialized product, optionally
+// filtered by status and warehouse
+func (h *SerialHandler) ProductSerials(c *gin.Context) {
+	var product models.Product
+	var units []models.SerialUnit
+	ctx := context.Background()
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.DB.WithContext(ctx).First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
+		})
+		return
+	}
+
+	query := h.DB.WithContext(ctx).Model(&models.SerialUnit{}).Where("product_id = ?", product.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if warehouseID := c.Query("warehouse_id"); warehouseID != "" {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.Order("serial_number").Offset(page.Offset()).Limit(page.Limit).Find(&units).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   units,
+		"count":  len(units),
+		"page":   page.Page,
+		"limit":  page.Limit,
+		"total":  total,
+	})
+}
+
+// Lookup finds the units with a serial number, for warranty claims
+func (h *SerialHandler) Lookup(c *gin.Context) {
+	var units []models.SerialUnit
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).
+		Where("serial_number = ?", c.Param("serial")).
+		Order("id").
+		Find(&units).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(units) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Serial not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   units,
+		"count":  len(units),
+	})
+}
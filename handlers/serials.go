@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lumiere11/pc-inventory-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errSerials is returned when the serials given with a stock change do not
+// match the product or the units that move
+var errSerials = errors.New("invalid serials")
+
+// serialStatusOut returns the status a unit takes when it leaves stock for a reason
+func serialStatusOut(reason string) string {
+	if reason == models.StockReasonSale {
+		return models.SerialSold
+	}
+	return models.SerialWrittenOff
+}
+
+// checkSerialCount verifies that a change on a product lists exactly one serial
+// per unit moved when the product is serialized, and none otherwise
+func checkSerialCount(product models.Product, quantity int32, serials []string) error {
+	if !product.Serialized {
+		if len(serials) > 0 {
+			return fmt.Errorf("%w: product %d is not serialized", errSerials, product.ID)
+		}
+		return nil
+	}
+	if quantity < 0 {
+		quantity = -quantity
+	}
+	if int32(len(serials)) != quantity {
+		return fmt.Errorf("%w: product %d is serialized, %d serials are required and %d were given", errSerials, product.ID, quantity, len(serials))
+	}
+	seen := make(map[string]bool, len(serials))
+	for _, serial := range serials {
+		if serial == "" || seen[serial] {
+			return fmt.Errorf("%w: serial '%s' is empty or repeated", errSerials, serial)
+		}
+		seen[serial] = true
+	}
+	return nil
+}
+
+// lockSerialUnits loads the units of a product with the given serials under a
+// row lock, keyed by serial number
+func lockSerialUnits(tx *gorm.DB, productID uint, serials []string) (map[string]models.SerialUnit, error) {
+	var units []models.SerialUnit
+	if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Where("product_id = ? AND serial_number IN ?", productID, serials).
+		Order("id").
+		Find(&units).Error; err != nil {
+		return nil, err
+	}
+	bySerial := make(map[string]models.SerialUnit, len(units))
+	for _, unit := range units {
+		bySerial[unit.SerialNumber] = unit
+	}
+	return bySerial, nil
+}
+
+// moveSerialUnits records on each unit the effect of a movement on a
+// serialized product. Units entering stock are created or brought back into
+// stock in the movement warehouse; units leaving must be in stock there.
+func moveSerialUnits(tx *gorm.DB, movement models.StockMovement, serials []string) error {
+	if len(serials) == 0 {
+		return nil
+	}
+
+	units, err := lockSerialUnits(tx, movement.ProductID, serials)
+	if err != nil {
+		return err
+	}
+
+	for _, serial := range serials {
+		unit, exists := units[serial]
+		updates := map[string]interface{}{"last_movement_id": movement.ID}
+
+		if movement.Delta > 0 {
+			if exists && unit.Status == models.SerialInStock {
+				return fmt.Errorf("%w: serial '%s' is already in stock", errSerials, serial)
+			}
+			updates["status"] = models.SerialInStock
+			updates["warehouse_id"] = movement.WarehouseID
+			if !exists {
+				unit = models.SerialUnit{
+					ProductID:      movement.ProductID,
+					SerialNumber:   serial,
+					Status:         models.SerialInStock,
+					WarehouseID:    movement.WarehouseID,
+					LastMovementID: &movement.ID,
+				}
+				if err := tx.Create(&unit).Error; err != nil {
+					return err
+				}
+				continue
+			}
+		} else {
+			if !exists || unit.Status != models.SerialInStock || unit.WarehouseID != movement.WarehouseID {
+				return fmt.Errorf("%w: serial '%s' is not in stock in warehouse %d", errSerials, serial, movement.WarehouseID)
+			}
+			updates["status"] = serialStatusOut(movement.Reason)
+			if movement.ReferenceType == models.ReferenceSalesOrderLine {
+				updates["sales_order_line_id"] = movement.ReferenceID
+			}
+		}
+
+		if err := tx.Model(&unit).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relocateSerialUnits moves in-stock units of a product from one warehouse to another
+func relocateSerialUnits(tx *gorm.DB, productID, fromWarehouseID, toWarehouseID uint, serials []string) error {
+	if len(serials) == 0 {
+		return nil
+	}
+
+	units, err := lockSerialUnits(tx, productID, serials)
+	if err != nil {
+		return err
+	}
+	for _, serial := range serials {
+		unit, exists := units[serial]
+		if !exists || unit.Status != models.SerialInStock || unit.WarehouseID != fromWarehouseID {
+			return fmt.Errorf("%w: serial '%s' is not in stock in warehouse %d", errSerials, serial, fromWarehouseID)
+		}
+	}
+	return tx.Model(&models.SerialUnit{}).
+		Where("product_id = ? AND serial_number IN ?", productID, serials).
+		Update("warehouse_id", toWarehouseID).Error
+}
+
+// updateSerialUnits applies updates to units of a product, all of which must
+// currently be in one of the from statuses
+func updateSerialUnits(tx *gorm.DB, productID uint, serials []string, updates map[string]interface{}, from ...string) error {
+	units, err := lockSerialUnits(tx, productID, serials)
+	if err != nil {
+		return err
+	}
+	for _, serial := range serials {
+		unit, exists := units[serial]
+		if !exists || !containsString(from, unit.Status) {
+			return fmt.Errorf("%w: serial '%s' is not %s", errSerials, serial, strings.Join(from, " or "))
+		}
+	}
+	return tx.Model(&models.SerialUnit{}).
+		Where("product_id = ? AND serial_number IN ?", productID, serials).
+		Updates(updates).Error
+}
+
+// containsString reports whether a slice holds a value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+	"github.com/lumiere11/pc-inventory-go/requests"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SpecHandler struct {
+	DB *gorm.DB
+}
+
+func NewSpecHandler(db *gorm.DB) *SpecHandler {
+	return &SpecHandler{
+		DB: db,
+	}
+}
+
+// Set replaces the technical attributes of a product
+func (h *SpecHandler) Set(c *gin.Context) {
+	var specReq requests.ProductSpecRequest
+	var product models.Product
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&specReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.DB.WithContext(ctx).First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
+		})
+		return
+	}
+	if product.IsKit {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A kit is checked through the specs of its components",
+		})
+		return
+	}
+
+	spec := models.ProductSpec{
+		ProductID:            product.ID,
+		Socket:               specReq.Socket,
+		Chipset:              specReq.Chipset,
+		MemoryType:           specReq.MemoryType,
+		FormFactor:           specReq.FormFactor,
+		SupportedFormFactors: specReq.SupportedFormFactors,
+		SupportedSockets:     specReq.SupportedSockets,
+		Wattage:              specReq.Wattage,
+		PowerDraw:            specReq.PowerDraw,
+		LengthMM:             specReq.LengthMM,
+		MaxGPULengthMM:       specReq.MaxGPULengthMM,
+	}
+	if err := h.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{
+			"socket", "chipset", "memory_type", "form_factor", "supported_form_factors",
+			"supported_sockets", "wattage", "power_draw", "length_mm", "max_gpu_length_mm", "updated_at",
+		}),
+	}).Create(&spec).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.DB.WithContext(ctx).Where("product_id = ?", product.ID).First(&spec).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    spec,
+		"message": "Product specs updated successfully",
+	})
+}
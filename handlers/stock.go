@@ -0,0 +1,379 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lumiere11/pc-inventory-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// errNegativeStock is returned when a movement would leave a product below zero
+	errNegativeStock = errors.New("stock cannot be negative")
+	// errVersionMismatch is returned when the product changed since the client read it
+	errVersionMismatch = errors.New("product version mismatch")
+	// errWarehouseNotFound is returned when a movement names an unknown warehouse
+	errWarehouseNotFound = errors.New("warehouse not found")
+)
+
+// stockChange describes a movement to apply to a product's stock
+type stockChange struct {
+	ProductID uint
+	// WarehouseID is the warehouse the units enter or leave, zero meaning the
+	// default warehouse
+	WarehouseID uint
+	Delta       int32
+	Reason      string
+	UserEmail   string
+	// ReferenceType and ReferenceID identify the record behind the change
+	ReferenceType string
+	ReferenceID   uint
+	// Serials lists the units that move, one per unit of the delta, and is
+	// required exactly for serialized products
+	Serials []string
+	// Lot describes where incoming units come from, for lot-tracked products
+	Lot *lotDetails
+	// ExpectedVersion, when set, makes the change fail unless the product is
+	// still at this version
+	ExpectedVersion uint
+}
+
+// applyStockChange updates the stock of a product in a warehouse, keeps the
+// product total in sync and writes the matching ledger entry. It must be called
+// inside a transaction so every write is committed or rolled back together.
+func applyStockChange(tx *gorm.DB, change stockChange) (models.StockMovement, error) {
+	var movement models.StockMovement
+
+	warehouseID, err := resolveWarehouseID(tx, change.WarehouseID)
+	if err != nil {
+		return movement, err
+	}
+
+	// Apply the delta atomically so concurrent writers never overwrite each other
+	query := tx.Model(&models.Product{}).Where("id = ? AND stock + ? >= 0 AND is_kit = ?", change.ProductID, change.Delta, false)
+	if change.ExpectedVersion != 0 {
+		query = query.Where("version = ?", change.ExpectedVersion)
+	}
+	result := query.Updates(map[string]interface{}{
+		"stock":   gorm.Expr("stock + ?", change.Delta),
+		"version": gorm.Expr("version + 1"),
+	})
+	if result.Error != nil {
+		return movement, result.Error
+	}
+
+	var product models.Product
+	if err := tx.Select("id", "stock", "version", "serialized", "lot_tracked", "is_kit").First(&product, change.ProductID).Error; err != nil {
+		return movement, err
+	}
+	if result.RowsAffected == 0 {
+		if product.IsKit {
+			return movement, errKitStock
+		}
+		if change.ExpectedVersion != 0 && product.Version != change.ExpectedVersion {
+			return movement, errVersionMismatch
+		}
+		return movement, errNegativeStock
+	}
+	if err := checkSerialCount(product, change.Delta, change.Serials); err != nil {
+		return movement, err
+	}
+
+	// Units promised to active reservations cannot be taken out by anything
+	// else. The update above locked the product row, so no reservation can be
+	// made meanwhile. Reservations are not tied to a warehouse, which is why
+	// transfers between warehouses never need this check.
+	if change.Delta < 0 {
+		reserved, err := reservedQuantities(tx, product.ID)
+		if err != nil {
+			return movement, err
+		}
+		if product.Stock < reserved[product.ID] {
+			return movement, fmt.Errorf("%w: product %d has %d units reserved", errInsufficientAvailable, product.ID, reserved[product.ID])
+		}
+	}
+
+	warehouseStock, err := addWarehouseStock(tx, product.ID, warehouseID, change.Delta)
+	if err != nil {
+		return movement, err
+	}
+
+	movement = models.StockMovement{
+		ProductID:           product.ID,
+		WarehouseID:         warehouseID,
+		Delta:               change.Delta,
+		StockAfter:          product.Stock,
+		WarehouseStockAfter: warehouseStock,
+		Reason:              change.Reason,
+		ReferenceType:       change.ReferenceType,
+		ReferenceID:         change.ReferenceID,
+		UserEmail:           change.UserEmail,
+	}
+	if err := tx.Create(&movement).Error; err != nil {
+		return movement, err
+	}
+
+	if err := moveSerialUnits(tx, movement, change.Serials); err != nil {
+		return movement, err
+	}
+
+	if err := applyLotChange(tx, product, movement, change.Lot); err != nil {
+		return movement, err
+	}
+
+	if err := flagStocktakeItems(tx, movement); err != nil {
+		return movement, err
+	}
+
+	if err := refreshKits(tx, movement); err != nil {
+		return movement, err
+	}
+
+	if err := syncProductStatus(tx, movement); err != nil {
+		return movement, err
+	}
+
+	if err := raiseLowStockAlert(tx, movement); err != nil {
+		return movement, err
+	}
+
+	return movement, nil
+}
+
+// lowStockSettings returns the minimum stock and reorder quantity that apply
+// to a product, falling back to the defaults of its category. ok is false when
+// neither the product nor its category define a minimum.
+func lowStockSettings(tx *gorm.DB, productID uint) (minStock, reorderQuantity int32, ok bool, err error) {
+	var product models.Product
+	if err := tx.Preload("Category").Select("id", "category_id", "min_stock", "reorder_quantity").
+		First(&product, productID).Error; err != nil {
+		return 0, 0, false, err
+	}
+
+	minStockSetting := product.MinStock
+	if minStockSetting == nil {
+		minStockSetting = product.Category.DefaultMinStock
+	}
+	reorderSetting := product.ReorderQuantity
+	if reorderSetting == nil {
+		reorderSetting = product.Category.DefaultReorderQuantity
+	}
+
+	if minStockSetting == nil {
+		return 0, 0, false, nil
+	}
+	if reorderSetting != nil {
+		reorderQuantity = *reorderSetting
+	}
+	return *minStockSetting, reorderQuantity, true, nil
+}
+
+// raiseLowStockAlert records an alert when a movement takes a product from its
+// minimum stock or above to below it, unless an unacknowledged alert is still open
+func raiseLowStockAlert(tx *gorm.DB, movement models.StockMovement) error {
+	if movement.Delta >= 0 {
+		return nil
+	}
+
+	minStock, reorderQuantity, ok, err := lowStockSettings(tx, movement.ProductID)
+	if err != nil || !ok {
+		return err
+	}
+	stockBefore := movement.StockAfter - movement.Delta
+	if movement.StockAfter >= minStock || stockBefore < minStock {
+		return nil
+	}
+
+	var open int64
+	if err := tx.Model(&models.LowStockAlert{}).
+		Where("product_id = ? AND acknowledged_at IS NULL", movement.ProductID).
+		Count(&open).Error; err != nil {
+		return err
+	}
+	if open > 0 {
+		return nil
+	}
+
+	alert := models.LowStockAlert{
+		ProductID:       movement.ProductID,
+		Stock:           movement.StockAfter,
+		MinStock:        minStock,
+		ReorderQuantity: reorderQuantity,
+		StockMovementID: movement.ID,
+	}
+	return tx.Create(&alert).Error
+}
+
+// transferStock moves units of a product between two warehouses. The product
+// total does not change; the ledger gets one movement out and one movement in.
+// Serialized products must list the serials of the units that move. The
+// product must still be at the expected version, which the transfer bumps.
+func transferStock(tx *gorm.DB, productID, expectedVersion, fromWarehouseID, toWarehouseID uint, quantity int32, serials []string, userEmail string) ([]models.StockMovement, error) {
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Select("id", "stock", "version", "serialized", "lot_tracked", "is_kit").First(&product, productID).Error; err != nil {
+		return nil, err
+	}
+	if product.Version != expectedVersion {
+		return nil, errVersionMismatch
+	}
+	if product.IsKit {
+		return nil, errKitStock
+	}
+	if err := tx.Model(&product).Update("version", gorm.Expr("version + 1")).Error; err != nil {
+		return nil, err
+	}
+	if err := checkSerialCount(product, quantity, serials); err != nil {
+		return nil, err
+	}
+
+	legs := []struct {
+		warehouseID uint
+		delta       int32
+	}{
+		{fromWarehouseID, -quantity},
+		{toWarehouseID, quantity},
+	}
+
+	movements := make([]models.StockMovement, 0, len(legs))
+	for _, leg := range legs {
+		warehouseID, err := resolveWarehouseID(tx, leg.warehouseID)
+		if err != nil {
+			return nil, err
+		}
+		warehouseStock, err := addWarehouseStock(tx, product.ID, warehouseID, leg.delta)
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, models.StockMovement{
+			ProductID:           product.ID,
+			WarehouseID:         warehouseID,
+			Delta:               leg.delta,
+			StockAfter:          product.Stock,
+			WarehouseStockAfter: warehouseStock,
+			Reason:              models.StockReasonTransfer,
+			UserEmail:           userEmail,
+		})
+	}
+
+	if err := tx.Create(&movements).Error; err != nil {
+		return nil, err
+	}
+	if err := relocateSerialUnits(tx, product.ID, movements[0].WarehouseID, movements[1].WarehouseID, serials); err != nil {
+		return nil, err
+	}
+	if product.LotTracked {
+		if err := transferLots(tx, product.ID, movements[0], movements[1]); err != nil {
+			return nil, err
+		}
+	}
+	for _, movement := range movements {
+		if err := flagStocktakeItems(tx, movement); err != nil {
+			return nil, err
+		}
+	}
+	return movements, nil
+}
+
+// resolveWarehouseID checks that a warehouse exists, returning the default
+// warehouse when the ID is zero
+func resolveWarehouseID(tx *gorm.DB, warehouseID uint) (uint, error) {
+	var warehouse models.Warehouse
+	query := tx.Where("is_default = ?", true)
+	if warehouseID != 0 {
+		query = tx.Where("id = ?", warehouseID)
+	}
+	if err := query.First(&warehouse).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errWarehouseNotFound
+		}
+		return 0, err
+	}
+	return warehouse.ID, nil
+}
+
+// lockWarehouseStock returns the stock row of a product in a warehouse,
+// creating it when missing, and locks it for the rest of the transaction
+func lockWarehouseStock(tx *gorm.DB, productID, warehouseID uint) (models.WarehouseStock, error) {
+	warehouseStock := models.WarehouseStock{ProductID: productID, WarehouseID: warehouseID}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&warehouseStock).Error; err != nil {
+		return warehouseStock, err
+	}
+	err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Where("product_id = ? AND warehouse_id = ?", productID, warehouseID).
+		First(&warehouseStock).Error
+	return warehouseStock, err
+}
+
+// addWarehouseStock atomically applies a delta to the stock of a product in a
+// warehouse and returns the new level
+func addWarehouseStock(tx *gorm.DB, productID, warehouseID uint, delta int32) (int32, error) {
+	if _, err := lockWarehouseStock(tx, productID, warehouseID); err != nil {
+		return 0, err
+	}
+
+	result := tx.Model(&models.WarehouseStock{}).
+		Where("product_id = ? AND warehouse_id = ? AND stock + ? >= 0", productID, warehouseID, delta).
+		Update("stock", gorm.Expr("stock + ?", delta))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, errNegativeStock
+	}
+
+	var warehouseStock models.WarehouseStock
+	if err := tx.Where("product_id = ? AND warehouse_id = ?", productID, warehouseID).First(&warehouseStock).Error; err != nil {
+		return 0, err
+	}
+	return warehouseStock.Stock, nil
+}
+
+// statusForStock returns the name of the status matching a stock level
+func statusForStock(stock int32) string {
+	if stock > 0 {
+		return models.StatusInStock
+	}
+	return models.StatusSoldOut
+}
+
+// statusIDByName looks up the ID of a status by its name
+func statusIDByName(tx *gorm.DB, name string) (uint, error) {
+	var status models.Status
+	if err := tx.Where("name = ?", name).First(&status).Error; err != nil {
+		return 0, fmt.Errorf("status '%s' not found: %w", name, err)
+	}
+	return status.ID, nil
+}
+
+// syncProductStatus moves a product to the status matching the stock level
+// left by a movement and records the transition when the status changes
+func syncProductStatus(tx *gorm.DB, movement models.StockMovement) error {
+	statusID, err := statusIDByName(tx, statusForStock(movement.StockAfter))
+	if err != nil {
+		return err
+	}
+
+	var product models.Product
+	if err := tx.Select("id", "status_id").First(&product, movement.ProductID).Error; err != nil {
+		return err
+	}
+	if product.StatusID == statusID {
+		return nil
+	}
+
+	if err := tx.Model(&product).Update("status_id", statusID).Error; err != nil {
+		return err
+	}
+
+	change := models.StatusChange{
+		ProductID:       product.ID,
+		FromStatusID:    product.StatusID,
+		ToStatusID:      statusID,
+		StockMovementID: movement.ID,
+		UserEmail:       movement.UserEmail,
+	}
+	return tx.Create(&change).Error
+}
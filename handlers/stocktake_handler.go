@@ -0,0 +1,598 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+	"github.com/lumiere11/pc-inventory-go/requests"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// errStocktakeClosed is returned when a stocktake is no longer open
+	errStocktakeClosed = errors.New("stocktake is no longer open")
+	// errNotInStocktake is returned when a count names a product outside the stocktake
+	errNotInStocktake = errors.New("product is not part of this stocktake")
+)
+
+type StocktakeHandler struct {
+	DB *gorm.DB
+}
+
+func NewStocktakeHandler(db *gorm.DB) *StocktakeHandler {
+	return &StocktakeHandler{
+		DB: db,
+	}
+}
+
+// varianceRow compares the counted quantity of a product with its stock
+type varianceRow struct {
+	ProductID          uint   `json:"product_id"`
+	Name               string `json:"name"`
+	SystemStock        int32  `json:"system_stock"`
+	CurrentStock       int32  `json:"current_stock"`
+	Counted            *int32 `json:"counted"`
+	Variance           *int32 `json:"variance"`
+	Counters           int    `json:"counters"`
+	ChangedDuringCount bool   `json:"changed_during_count"`
+	// Trashed products are reported but never adjusted
+	Trashed bool `json:"trashed"`
+}
+
+// flagStocktakeItems marks a product as changed in every open stocktake of the
+// movement warehouse, unless the movement comes from a stocktake itself
+func flagStocktakeItems(tx *gorm.DB, movement models.StockMovement) error {
+	if movement.ReferenceType == models.ReferenceStocktake {
+		return nil
+	}
+	return tx.Model(&models.StocktakeItem{}).
+		Where("product_id = ? AND warehouse_id = ? AND changed_during_count = ?", movement.ProductID, movement.WarehouseID, false).
+		Where("stocktake_id IN (?)", tx.Model(&models.Stocktake{}).Select("id").Where("status = ?", models.StocktakeOpen)).
+		Update("changed_during_count", true).Error
+}
+
+// List returns stocktakes, optionally filtered by status
+func (h *StocktakeHandler) List(c *gin.Context) {
+	var stocktakes []models.Stocktake
+	ctx := context.Background()
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := h.DB.WithContext(ctx).Model(&models.Stocktake{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.Preload("Categories").Order("id DESC").Offset(page.Offset()).Limit(page.Limit).Find(&stocktakes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   stocktakes,
+		"count":  len(stocktakes),
+		"page":   page.Page,
+		"limit":  page.Limit,
+		"total":  total,
+	})
+}
+
+func (h *StocktakeHandler) Get(c *gin.Context) {
+	var stocktake models.Stocktake
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).
+		Preload("Categories").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("product_id") }).
+		First(&stocktake, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Stocktake not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   stocktake,
+	})
+}
+
+// Create opens a stocktake for the products of the given categories and their
+// subcategories, recording their current stock in the warehouse
+func (h *StocktakeHandler) Create(c *gin.Context) {
+	var stocktakeReq requests.StocktakeRequest
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&stocktakeReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var categories []models.Category
+	if err := h.DB.WithContext(ctx).Where("id IN ?", stocktakeReq.CategoryIDs).Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(categories) != len(stocktakeReq.CategoryIDs) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Category not found",
+		})
+		return
+	}
+
+	tree, err := loadCategoryTree(h.DB.WithContext(ctx))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var scope []uint
+	for _, category := range categories {
+		scope = append(scope, tree.descendants(category.ID)...)
+	}
+
+	stocktake := models.Stocktake{
+		Name:       stocktakeReq.Name,
+		Status:     models.StocktakeOpen,
+		Categories: categories,
+		OpenedBy:   c.GetString("email"),
+	}
+	err = h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		warehouseID, err := resolveWarehouseID(tx, stocktakeReq.WarehouseID)
+		if err != nil {
+			return err
+		}
+		stocktake.WarehouseID = warehouseID
+
+		// Serialized products are counted by serial, not by quantity, and kits
+		// are counted through their components
+		var rows []struct {
+			ID    uint
+			Stock int32
+		}
+		if err := tx.Model(&models.Product{}).
+			Select("products.id, COALESCE(warehouse_stocks.stock, 0) AS stock").
+			Joins("LEFT JOIN warehouse_stocks ON warehouse_stocks.product_id = products.id AND warehouse_stocks.warehouse_id = ?", warehouseID).
+			Where("products.category_id IN ? AND products.serialized = ? AND products.is_kit = ?", scope, false, false).
+			Order("products.id").
+			Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Omit("Categories.*").Create(&stocktake).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		items := make([]models.StocktakeItem, 0, len(rows))
+		for _, row := range rows {
+			items = append(items, models.StocktakeItem{
+				StocktakeID: stocktake.ID,
+				ProductID:   row.ID,
+				WarehouseID: warehouseID,
+				SystemStock: row.Stock,
+			})
+		}
+		stocktake.Items = items
+		return tx.Create(&stocktake.Items).Error
+	})
+	if errors.Is(err, errWarehouseNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Warehouse not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"data":    stocktake,
+		"message": "Stocktake opened successfully",
+	})
+}
+
+// SubmitCounts records the quantities the current user counted. Counting a
+// product again replaces that user's previous count.
+func (h *StocktakeHandler) SubmitCounts(c *gin.Context) {
+	var countsReq requests.StocktakeCountsRequest
+	var stocktake models.Stocktake
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&countsReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.DB.WithContext(ctx).First(&stocktake, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Stocktake not found",
+		})
+		return
+	}
+
+	counter := c.GetString("email")
+	counts := make([]models.StocktakeCount, 0, len(countsReq.Counts))
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockOpenStocktake(tx, &stocktake); err != nil {
+			return err
+		}
+
+		for _, countReq := range countsReq.Counts {
+			var item models.StocktakeItem
+			if err := tx.Where("stocktake_id = ? AND product_id = ?", stocktake.ID, countReq.ProductID).
+				First(&item).Error; err != nil {
+				return fmt.Errorf("%w: %d", errNotInStocktake, countReq.ProductID)
+			}
+			counts = append(counts, models.StocktakeCount{
+				StocktakeID: stocktake.ID,
+				ProductID:   countReq.ProductID,
+				Counter:     counter,
+				Quantity:    *countReq.Quantity,
+			})
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.AssignmentColumns([]string{"quantity", "updated_at"}),
+		}).Create(&counts).Error
+	})
+	if errors.Is(err, errStocktakeClosed) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Stocktake is " + stocktake.Status,
+		})
+		return
+	}
+	if errors.Is(err, errNotInStocktake) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    counts,
+		"message": "Counts recorded successfully",
+	})
+}
+
+// Variance compares the counted quantities with the stock of each product
+func (h *StocktakeHandler) Variance(c *gin.Context) {
+	var stocktake models.Stocktake
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).First(&stocktake, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Stocktake not found",
+		})
+		return
+	}
+
+	rows, err := stocktakeVariance(h.DB.WithContext(ctx), stocktake)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var counted, changed, mismatched, trashed int
+	for _, row := range rows {
+		if row.Trashed {
+			trashed++
+		}
+		if row.Counted != nil {
+			counted++
+			if *row.Variance != 0 {
+				mismatched++
+			}
+		}
+		if row.ChangedDuringCount {
+			changed++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   rows,
+		"count":  len(rows),
+		"summary": gin.H{
+			"counted":              counted,
+			"uncounted":            len(rows) - counted,
+			"with_variance":        mismatched,
+			"changed_during_count": changed,
+			"trashed":              trashed,
+		},
+	})
+}
+
+// Commit adjusts the stock of every counted product to its counted quantity in
+// a single transaction and closes the stocktake. Products nobody counted, and
+// products moved to the trash since the count started, are left untouched. A
+// count that the serials, lots or reservations of a product cannot account for
+// stops the whole commit with a conflict.
+func (h *StocktakeHandler) Commit(c *gin.Context) {
+	var commitReq requests.CommitStocktakeRequest
+	var stocktake models.Stocktake
+	ctx := context.Background()
+
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&commitReq); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
+
+	if err := h.DB.WithContext(ctx).First(&stocktake, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Stocktake not found",
+		})
+		return
+	}
+
+	var movements []models.StockMovement
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockOpenStocktake(tx, &stocktake); err != nil {
+			return err
+		}
+
+		rows, err := stocktakeVariance(tx, stocktake)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if row.Counted == nil || row.Trashed || (commitReq.ExcludeChanged && row.ChangedDuringCount) {
+				continue
+			}
+
+			// Lock the warehouse level so the delta matches what is applied
+			warehouseStock, err := lockWarehouseStock(tx, row.ProductID, stocktake.WarehouseID)
+			if err != nil {
+				return err
+			}
+			delta := *row.Counted - warehouseStock.Stock
+			if delta == 0 {
+				continue
+			}
+
+			movement, err := applyStockChange(tx, stockChange{
+				ProductID:     row.ProductID,
+				WarehouseID:   stocktake.WarehouseID,
+				Delta:         delta,
+				Reason:        models.StockReasonAdjustment,
+				UserEmail:     c.GetString("email"),
+				ReferenceType: models.ReferenceStocktake,
+				ReferenceID:   stocktake.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("product %d: %w", row.ProductID, err)
+			}
+			movements = append(movements, movement)
+
+			if err := tx.Model(&models.StocktakeItem{}).
+				Where("stocktake_id = ? AND product_id = ?", stocktake.ID, row.ProductID).
+				Update("stock_movement_id", movement.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		stocktake.Status = models.StocktakeCommitted
+		stocktake.CommittedBy = c.GetString("email")
+		stocktake.CommittedAt = &now
+		return tx.Model(&stocktake).Updates(map[string]interface{}{
+			"status":       stocktake.Status,
+			"committed_by": stocktake.CommittedBy,
+			"committed_at": now,
+		}).Error
+	})
+	if errors.Is(err, errStocktakeClosed) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Stocktake is " + stocktake.Status,
+		})
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// A counted product went to the trash while the commit ran; the next
+		// attempt reports it as trashed and leaves it out
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "A counted product was moved to the trash, commit again to leave it out",
+		})
+		return
+	}
+	if errors.Is(err, errInsufficientAvailable) || errors.Is(err, errSerials) || errors.Is(err, errLots) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"stocktake": stocktake,
+			"movements": movements,
+		},
+		"message": "Stocktake committed successfully",
+	})
+}
+
+// Cancel closes an open stocktake without touching the stock
+func (h *StocktakeHandler) Cancel(c *gin.Context) {
+	var stocktake models.Stocktake
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).First(&stocktake, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Stocktake not found",
+		})
+		return
+	}
+
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockOpenStocktake(tx, &stocktake); err != nil {
+			return err
+		}
+		stocktake.Status = models.StocktakeCancelled
+		return tx.Model(&stocktake).Update("status", stocktake.Status).Error
+	})
+	if errors.Is(err, errStocktakeClosed) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Stocktake is " + stocktake.Status,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    stocktake,
+		"message": "Stocktake cancelled successfully",
+	})
+}
+
+// lockOpenStocktake reloads a stocktake under a row lock and checks that it is open
+func lockOpenStocktake(tx *gorm.DB, stocktake *models.Stocktake) error {
+	if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		First(stocktake, stocktake.ID).Error; err != nil {
+		return err
+	}
+	if stocktake.Status != models.StocktakeOpen {
+		return errStocktakeClosed
+	}
+	return nil
+}
+
+// stocktakeVariance builds the variance report of a stocktake. The variance
+// is the counted quantity minus the current stock of the warehouse.
+func stocktakeVariance(db *gorm.DB, stocktake models.Stocktake) ([]varianceRow, error) {
+	var items []struct {
+		ProductID          uint
+		Name               string
+		SystemStock        int32
+		CurrentStock       int32
+		ChangedDuringCount bool
+		Trashed            bool
+	}
+	if err := db.Model(&models.StocktakeItem{}).
+		Select(`stocktake_items.product_id, products.name, stocktake_items.system_stock,
+			COALESCE(warehouse_stocks.stock, 0) AS current_stock, stocktake_items.changed_during_count,
+			products.deleted_at IS NOT NULL AS trashed`).
+		Joins("JOIN products ON products.id = stocktake_items.product_id").
+		Joins("LEFT JOIN warehouse_stocks ON warehouse_stocks.product_id = stocktake_items.product_id AND warehouse_stocks.warehouse_id = stocktake_items.warehouse_id").
+		Where("stocktake_items.stocktake_id = ?", stocktake.ID).
+		Order("stocktake_items.product_id").
+		Scan(&items).Error; err != nil {
+		return nil, err
+	}
+
+	var totals []struct {
+		ProductID uint
+		Total     int32
+		Counters  int
+	}
+	if err := db.Model(&models.StocktakeCount{}).
+		Select("product_id, SUM(quantity) AS total, COUNT(*) AS counters").
+		Where("stocktake_id = ?", stocktake.ID).
+		Group("product_id").
+		Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+	counted := make(map[uint]int, len(totals))
+	for i, total := range totals {
+		counted[total.ProductID] = i
+	}
+
+	rows := make([]varianceRow, 0, len(items))
+	for _, item := range items {
+		row := varianceRow{
+			ProductID:          item.ProductID,
+			Name:               item.Name,
+			SystemStock:        item.SystemStock,
+			CurrentStock:       item.CurrentStock,
+			ChangedDuringCount: item.ChangedDuringCount,
+			Trashed:            item.Trashed,
+		}
+		if i, ok := counted[item.ProductID]; ok {
+			total := totals[i].Total
+			variance := total - item.CurrentStock
+			row.Counted = &total
+			row.Variance = &variance
+			row.Counters = totals[i].Counters
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+	"github.com/lumiere11/pc-inventory-go/requests"
+	"gorm.io/gorm"
+)
+
+type SupplierHandler struct {
+	DB *gorm.DB
+}
+
+func NewSupplierHandler(db *gorm.DB) *SupplierHandler {
+	return &SupplierHandler{
+		DB: db,
+	}
+}
+
+func (h *SupplierHandler) List(c *gin.Context) {
+	var suppliers []models.Supplier
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).Order("name").Find(&suppliers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   suppliers,
+		"count":  len(suppliers),
+	})
+}
+
+func (h *SupplierHandler) Get(c *gin.Context) {
+	var supplier models.Supplier
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).First(&supplier, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Supplier not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   supplier,
+	})
+}
+
+func (h *SupplierHandler) Create(c *gin.Context) {
+	var supplierReq requests.SupplierRequest
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&supplierReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if h.nameTaken(ctx, supplierReq.Name, 0) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "A supplier with this name already exists",
+		})
+		return
+	}
+
+	supplier := models.Supplier{
+		Name:        supplierReq.Name,
+		ContactName: supplierReq.ContactName,
+		Email:       supplierReq.Email,
+		Phone:       supplierReq.Phone,
+	}
+	if err := h.DB.WithContext(ctx).Create(&supplier).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"data":    supplier,
+		"message": "Supplier created successfully",
+	})
+}
+
+func (h *SupplierHandler) Update(c *gin.Context) {
+	var supplierReq requests.SupplierRequest
+	var supplier models.Supplier
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&supplierReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.DB.WithContext(ctx).First(&supplier, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Supplier not found",
+		})
+		return
+	}
+
+	if h.nameTaken(ctx, supplierReq.Name, supplier.ID) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "A supplier with this name already exists",
+		})
+		return
+	}
+
+	supplier.Name = supplierReq.Name
+	supplier.ContactName = supplierReq.ContactName
+	supplier.Email = supplierReq.Email
+	supplier.Phone = supplierReq.Phone
+	if err := h.DB.WithContext(ctx).Model(&supplier).Updates(map[string]interface{}{
+		"name":         supplier.Name,
+		"contact_name": supplier.ContactName,
+		"email":        supplier.Email,
+		"phone":        supplier.Phone,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    supplier,
+		"message": "Supplier updated successfully",
+	})
+}
+
+// nameTaken reports whether another supplier already uses the name
+func (h *SupplierHandler) nameTaken(ctx context.Context, name string, excludeID uint) bool {
+	var existing models.Supplier
+	err := h.DB.WithContext(ctx).Where("name = ? AND id <> ?", name, excludeID).First(&existing).Error
+	return !errors.Is(err, gorm.ErrRecordNotFound)
+}
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumiere11/pc-inventory-go/models"
+	"github.com/lumiere11/pc-inventory-go/requests"
+	"gorm.io/gorm"
+)
+
+type WarehouseHandler struct {
+	DB *gorm.DB
+}
+
+func NewWarehouseHandler(db *gorm.DB) *WarehouseHandler {
+	return &WarehouseHandler{
+		DB: db,
+	}
+}
+
+func (h *WarehouseHandler) List(c *gin.Context) {
+	var warehouses []models.Warehouse
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).Order("name").Find(&warehouses).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   warehouses,
+		"count":  len(warehouses),
+	})
+}
+
+func (h *WarehouseHandler) Create(c *gin.Context) {
+	var warehouseReq requests.WarehouseRequest
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&warehouseReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if h.nameOrCodeTaken(ctx, warehouseReq, 0) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "A warehouse with this name or code already exists",
+		})
+		return
+	}
+
+	warehouse := models.Warehouse{
+		Name:      warehouseReq.Name,
+		Code:      warehouseReq.Code,
+		IsDefault: warehouseReq.IsDefault,
+	}
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&warehouse).Error; err != nil {
+			return err
+		}
+		return ensureSingleDefault(tx, warehouse)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"data":    warehouse,
+		"message": "Warehouse created successfully",
+	})
+}
+
+// Update renames a warehouse or makes it the default one
+func (h *WarehouseHandler) Update(c *gin.Context) {
+	var warehouseReq requests.WarehouseRequest
+	var warehouse models.Warehouse
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&warehouseReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.DB.WithContext(ctx).First(&warehouse, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Warehouse not found",
+		})
+		return
+	}
+
+	if h.nameOrCodeTaken(ctx, warehouseReq, warehouse.ID) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "A warehouse with this name or code already exists",
+		})
+		return
+	}
+
+	// There must always be a default warehouse to receive unqualified stock changes
+	if warehouse.IsDefault && !warehouseReq.IsDefault {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Mark another warehouse as default instead",
+		})
+		return
+	}
+
+	warehouse.Name = warehouseReq.Name
+	warehouse.Code = warehouseReq.Code
+	warehouse.IsDefault = warehouseReq.IsDefault
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&warehouse).Updates(map[string]interface{}{
+			"name":       warehouse.Name,
+			"code":       warehouse.Code,
+			"is_default": warehouse.IsDefault,
+		}).Error; err != nil {
+			return err
+		}
+		return ensureSingleDefault(tx, warehouse)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    warehouse,
+		"message": "Warehouse updated successfully",
+	})
+}
+
+// StockLevels returns the stock of a product in every warehouse holding it
+func (h *WarehouseHandler) StockLevels(c *gin.Context) {
+	var product models.Product
+	ctx := context.Background()
+
+	if err := h.DB.WithContext(ctx).First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
+		})
+		return
+	}
+
+	var levels []models.WarehouseStock
+	if err := h.DB.WithContext(ctx).
+		Preload("Warehouse").
+		Where("product_id = ?", product.ID).
+		Order("warehouse_id").
+		Find(&levels).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"product_id": product.ID,
+			"stock":      product.Stock,
+			"warehouses": levels,
+		},
+	})
+}
+
+// Transfer moves units of a product from one warehouse to another atomically.
+// Like any other product write it requires If-Match.
+func (h *WarehouseHandler) Transfer(c *gin.Context) {
+	var transferReq requests.TransferStockRequest
+	var product models.Product
+	ctx := context.Background()
+
+	if err := c.ShouldBindJSON(&transferReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.DB.WithContext(ctx).First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Product not found",
+		})
+		return
+	}
+	if !checkIfMatch(c, product) {
+		return
+	}
+
+	var movements []models.StockMovement
+	err := h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		movements, err = transferStock(tx, product.ID, product.Version, transferReq.FromWarehouseID, transferReq.ToWarehouseID, transferReq.Quantity, transferReq.Serials, c.GetString("email"))
+		return err
+	})
+	if errors.Is(err, errVersionMismatch) || errors.Is(err, gorm.ErrRecordNotFound) {
+		if h.DB.WithContext(ctx).First(&product, product.ID).Error != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"data":    gin.H{},
+				"message": "Product not found",
+			})
+			return
+		}
+		respondVersionMismatch(c, product)
+		return
+	}
+	if errors.Is(err, errWarehouseNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Warehouse not found",
+		})
+		return
+	}
+	if errors.Is(err, errSerials) || errors.Is(err, errLots) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, errNegativeStock) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": "Not enough stock in the source warehouse",
+		})
+		return
+	}
+	if errors.Is(err, errKitStock) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"data":    gin.H{},
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    movements,
+		"message": "Stock transferred successfully",
+	})
+}
+
+// nameOrCodeTaken reports whether another warehouse already uses the name or code
+func (h *WarehouseHandler) nameOrCodeTaken(ctx context.Context, warehouseReq requests.WarehouseRequest, excludeID uint) bool {
+	var existing models.Warehouse
+	err := h.DB.WithContext(ctx).
+		Where("(name = ? OR code = ?) AND id <> ?", warehouseReq.Name, warehouseReq.Code, excludeID).
+		First(&existing).Error
+	return !errors.Is(err, gorm.ErrRecordNotFound)
+}
+
+// ensureSingleDefault clears the default flag of every other warehouse when
+// the given one is the default
+func ensureSingleDefault(tx *gorm.DB, warehouse models.Warehouse) error {
+	if !warehouse.IsDefault {
+		return nil
+	}
+	return tx.Model(&models.Warehouse{}).
+		Where("id <> ? AND is_default = ?", warehouse.ID, true).
+		Update("is_default", false).Error
+}
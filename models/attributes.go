@@ -0,0 +1,88 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Attributes holds the values of the attributes a product's category defines,
+// keyed by attribute name. It is stored as a JSON column.
+type Attributes map[string]interface{}
+
+// Value stores the attributes as a JSON object
+func (a Attributes) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan reads the attributes from a JSON column
+func (a *Attributes) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*a = Attributes{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Attributes", value)
+	}
+
+	attributes := Attributes{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &attributes); err != nil {
+			return err
+		}
+	}
+	*a = attributes
+	return nil
+}
+
+// StringList is a list of strings stored as a JSON array
+type StringList []string
+
+// Value stores the list as a JSON array
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan reads the list from a JSON column
+func (l *StringList) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*l = StringList{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", value)
+	}
+
+	list := StringList{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &list); err != nil {
+			return err
+		}
+	}
+	*l = list
+	return nil
+}
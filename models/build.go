@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Build is a named PC configuration saved by a user. Sharing it gives it a
+// token that lets anyone read it without signing in.
+type Build struct {
+	gorm.Model
+	Name       string      `gorm:"not null;size:100" json:"name"`
+	UserEmail  string      `gorm:"not null;index;size:255" json:"user_email"`
+	ShareToken *string     `gorm:"size:64;uniqueIndex" json:"share_token"`
+	Items      []BuildItem `gorm:"foreignKey:BuildID" json:"items"`
+}
+
+// BuildItem is a product and the number of units a build uses
+type BuildItem struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	BuildID   uint      `gorm:"not null;uniqueIndex:idx_build_items_build_product" json:"build_id"`
+	ProductID uint      `gorm:"not null;uniqueIndex:idx_build_items_build_product;index" json:"product_id"`
+	Product   *Product  `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Quantity  int32     `gorm:"not null" json:"quantity"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -3,7 +3,15 @@ package models
 import "gorm.io/gorm"
 
 type Category struct {
-	Name     string
+	Name     string    `gorm:"not null;unique;size:100" json:"name"`
+	ParentID *uint     `gorm:"index" json:"parent_id"`
+	Parent   *Category `gorm:"foreignKey:ParentID" json:"parent,omitempty"`
 	Products []Product `gorm:"foreignKey:CategoryID" json:"products,omitempty"`
+	// Low stock settings for products that do not define their own
+	DefaultMinStock        *int32 `json:"default_min_stock"`
+	DefaultReorderQuantity *int32 `json:"default_reorder_quantity"`
+	// ComponentType marks the PC components in this category and the ones below
+	// it that do not set their own
+	ComponentType string `gorm:"size:20" json:"component_type"`
 	gorm.Model
 }
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Attribute types a category can define
+const (
+	AttributeString  = "string"
+	AttributeNumber  = "number"
+	AttributeInteger = "integer"
+	AttributeBoolean = "boolean"
+	AttributeEnum    = "enum"
+)
+
+// CategoryAttribute describes an attribute the products of a category and of
+// every category below it carry, such as the capacity of a memory module.
+// Attributes are catalog data to show and filter on; what the build checker
+// compares lives in ProductSpec, whose fields cannot be redefined.
+type CategoryAttribute struct {
+	ID         uint   `gorm:"primarykey" json:"id"`
+	CategoryID uint   `gorm:"not null;uniqueIndex:idx_category_attributes_category_name" json:"category_id"`
+	Name       string `gorm:"not null;size:50;uniqueIndex:idx_category_attributes_category_name" json:"name"`
+	Type       string `gorm:"not null;size:20" json:"type"`
+	Unit       string `gorm:"size:20" json:"unit"`
+	// AllowedValues lists the values an enum, or optionally a string, may take
+	AllowedValues StringList `gorm:"type:json" json:"allowed_values"`
+	Required      bool       `gorm:"not null;default:false" json:"required"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
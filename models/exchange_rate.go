@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExchangeRate is one version of the rate between two currencies. Rows are
+// never edited: a new rate is a new row with a later EffectiveFrom, so past
+// conversions can always be traced back to the rate they used.
+type ExchangeRate struct {
+	gorm.Model
+	BaseCurrency  string    `gorm:"not null;size:3;index:idx_exchange_rates_pair" json:"base_currency"`
+	QuoteCurrency string    `gorm:"not null;size:3;index:idx_exchange_rates_pair" json:"quote_currency"`
+	Rate          string    `gorm:"type:decimal(18,6);not null" json:"rate"` // Units of QuoteCurrency per one BaseCurrency
+	EffectiveFrom time.Time `gorm:"not null;index" json:"effective_from"`
+	CreatedBy     string    `gorm:"size:255" json:"created_by"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// KitComponent is a product and quantity that make up one unit of a kit. A
+// kit has no stock of its own: its stock is the number of complete kits its
+// components can build, and selling a kit takes out each component.
+type KitComponent struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	KitID       uint      `gorm:"not null;uniqueIndex:idx_kit_components_kit_component" json:"kit_id"`
+	ComponentID uint      `gorm:"not null;uniqueIndex:idx_kit_components_kit_component;index" json:"component_id"`
+	Component   *Product  `gorm:"foreignKey:ComponentID" json:"component,omitempty"`
+	Quantity    int32     `gorm:"not null" json:"quantity"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Lot is a batch of a lot-tracked product received into a warehouse. The
+// remaining units of the lots of a product in a warehouse always add up to the
+// stock of that warehouse, and units leave the oldest lots first.
+type Lot struct {
+	gorm.Model
+	ProductID         uint       `gorm:"not null;index:idx_lots_product_warehouse" json:"product_id"`
+	WarehouseID       uint       `gorm:"not null;index:idx_lots_product_warehouse" json:"warehouse_id"`
+	LotNumber         string     `gorm:"size:100;index" json:"lot_number"`
+	SupplierID        *uint      `gorm:"index" json:"supplier_id"`
+	SupplierReference string     `gorm:"size:100" json:"supplier_reference"`
+	ReceivedAt        time.Time  `gorm:"not null;index" json:"received_at"`
+	WarrantyExpiresAt *time.Time `gorm:"index" json:"warranty_expires_at"`
+	Quantity          int32      `gorm:"not null" json:"quantity"`
+	Remaining         int32      `gorm:"not null" json:"remaining"`
+}
+
+// LotMovement records how many units of a lot a stock movement took or added
+type LotMovement struct {
+	ID              uint      `gorm:"primarykey" json:"id"`
+	StockMovementID uint      `gorm:"not null;index" json:"stock_movement_id"`
+	LotID           uint      `gorm:"not null;index" json:"lot_id"`
+	Quantity        int32     `gorm:"not null" json:"quantity"`
+	CreatedAt       time.Time `json:"created_at"`
+}
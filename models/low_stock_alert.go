@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LowStockAlert is raised when a stock movement takes a product below its
+// minimum stock. While an alert is unacknowledged no new one is raised for the
+// same product.
+type LowStockAlert struct {
+	gorm.Model
+	ProductID       uint       `gorm:"not null;index" json:"product_id"`
+	Product         Product    `gorm:"foreignKey:ProductID" json:"product"`
+	Stock           int32      `gorm:"not null" json:"stock"`
+	MinStock        int32      `gorm:"not null" json:"min_stock"`
+	ReorderQuantity int32      `gorm:"not null;default:0" json:"reorder_quantity"`
+	StockMovementID uint       `json:"stock_movement_id"`
+	AcknowledgedAt  *time.Time `gorm:"index" json:"acknowledged_at"`
+	AcknowledgedBy  string     `gorm:"size:255" json:"acknowledged_by"`
+}
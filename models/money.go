@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the ISO 4217 code used when a price has no explicit currency
+const DefaultCurrency = "MXN"
+
+// ErrInvalidMoney is returned when an amount is not a non-negative decimal
+// with at most two fractional digits
+var ErrInvalidMoney = errors.New("amount must be a non-negative decimal with at most two decimal places")
+
+// Money is an exact amount stored in minor units (cents). It is rendered in
+// JSON as a decimal string such as "199.99".
+type Money int64
+
+// ParseMoney parses a decimal string such as "199.99" into minor units
+func ParseMoney(value string) (Money, error) {
+	whole, frac, hasFrac := strings.Cut(strings.TrimSpace(value), ".")
+	if !isDigits(whole) || (hasFrac && (len(frac) > 2 || !isDigits(frac))) {
+		return 0, ErrInvalidMoney
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > (math.MaxInt64-99)/100 {
+		return 0, ErrInvalidMoney
+	}
+	cents, _ := strconv.ParseInt(frac, 10, 64)
+
+	return Money(units*100 + cents), nil
+}
+
+// String renders the amount as a decimal with two fractional digits
+func (m Money) String() string {
+	sign := ""
+	value := int64(m)
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, value/100, value%100)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON accepts both "199.99" and 199.99, parsing the digits exactly
+func (m *Money) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	parsed, err := ParseMoney(value)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Sources of a price change
+const (
+	PriceSourceManual    = "manual"
+	PriceSourceScheduled = "scheduled"
+)
+
+// PriceChange records a product price before and after an edit
+type PriceChange struct {
+	gorm.Model
+	ProductID        uint   `gorm:"not null;index" json:"product_id"`
+	OldPrice         Money  `gorm:"column:old_price_cents;not null" json:"old_price"`
+	OldCurrency      string `gorm:"size:3;not null" json:"old_currency"`
+	NewPrice         Money  `gorm:"column:new_price_cents;not null" json:"new_price"`
+	NewCurrency      string `gorm:"size:3;not null" json:"new_currency"`
+	Source           string `gorm:"not null;size:25" json:"source"`
+	ScheduledPriceID *uint  `json:"scheduled_price_id"`
+	UserEmail        string `gorm:"size:255" json:"user_email"`
+}
+
+// ScheduledPrice is a price that becomes effective at a future time
+type ScheduledPrice struct {
+	gorm.Model
+	ProductID   uint       `gorm:"not null;index" json:"product_id"`
+	Price       Money      `gorm:"column:price_cents;not null" json:"price"`
+	Currency    string     `gorm:"size:3;not null" json:"currency"`
+	EffectiveAt time.Time  `gorm:"not null;index" json:"effective_at"`
+	AppliedAt   *time.Time `json:"applied_at"`
+	CancelledAt *time.Time `json:"cancelled_at"`
+	CreatedBy   string     `gorm:"size:255" json:"created_by"`
+}
@@ -4,14 +4,33 @@ import "gorm.io/gorm"
 
 type Product struct {
 	gorm.Model
-	Name        string   `gorm:"not null;unique;size:100" json:"name"`
+	Name        string   `gorm:"not null;index;size:100" json:"name"`
 	Brand       string   `json:"brand"`
 	Model2      string   `json:"model"`
 	Description string   `gorm:"not null;size:255" json:"description"`
 	Stock       int32    `json:"stock"`
-	Price       float32  `json:"price"`
+	Price       Money    `gorm:"column:price_cents;not null;default:0" json:"price"`
+	Currency    string   `gorm:"size:3;not null;default:MXN" json:"currency"`
 	StatusID    uint     `json:"status_id"`
 	Status      Status   `gorm:"foreignKey:StatusID" json:"status"`
 	CategoryID  uint     `gorm:"not null" json:"category_id"`
 	Category    Category `gorm:"foreignKey:CategoryID" json:"category"`
+	Version     uint     `gorm:"not null;default:1" json:"version"`
+	// Serialized products track every unit by serial number in SerialUnit
+	Serialized bool `gorm:"not null;default:false" json:"serialized"`
+	// LotTracked products keep their stock in lots consumed oldest first
+	LotTracked bool `gorm:"not null;default:false" json:"lot_tracked"`
+	// IsKit products are built from Components; their stock is derived
+	IsKit      bool           `gorm:"not null;default:false" json:"is_kit"`
+	Components []KitComponent `gorm:"foreignKey:KitID" json:"components,omitempty"`
+	// Spec holds the technical attributes compared by the build checker
+	Spec *ProductSpec `gorm:"foreignKey:ProductID" json:"spec,omitempty"`
+	// Attributes follow the attribute schema of the category and its ancestors
+	Attributes Attributes `gorm:"type:json" json:"attributes"`
+	// MinStock and ReorderQuantity override the defaults of the category
+	MinStock        *int32 `json:"min_stock"`
+	ReorderQuantity *int32 `json:"reorder_quantity"`
+	// Available is the stock not held by active reservations. It is computed on
+	// read and never stored.
+	Available int32 `gorm:"-" json:"available"`
 }
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Component types a category can be marked with. The build checker uses them
+// to know which technical attributes of a product to compare.
+const (
+	ComponentCPU         = "cpu"
+	ComponentMotherboard = "motherboard"
+	ComponentMemory      = "memory"
+	ComponentGPU         = "gpu"
+	ComponentPSU         = "psu"
+	ComponentCase        = "case"
+	ComponentCooler      = "cooler"
+	ComponentStorage     = "storage"
+)
+
+// ProductSpec holds the technical attributes of a PC component. Which fields
+// apply depends on the component type of the product's category; the rest are
+// left empty. Unlike category attributes, which admins define freely for the
+// catalog, these fields have a fixed meaning the build checker relies on.
+type ProductSpec struct {
+	ID        uint `gorm:"primarykey" json:"id"`
+	ProductID uint `gorm:"not null;uniqueIndex" json:"product_id"`
+	// Socket of a CPU or motherboard, e.g. AM5 or LGA1700
+	Socket string `gorm:"size:30" json:"socket"`
+	// Chipset of a motherboard, e.g. B650
+	Chipset string `gorm:"size:30" json:"chipset"`
+	// MemoryType of a motherboard or memory module, e.g. DDR5
+	MemoryType string `gorm:"size:20" json:"memory_type"`
+	// FormFactor of a motherboard, e.g. ATX or Micro-ATX
+	FormFactor string `gorm:"size:20" json:"form_factor"`
+	// SupportedFormFactors lists the motherboard form factors a case takes,
+	// separated by commas
+	SupportedFormFactors string `gorm:"size:100" json:"supported_form_factors"`
+	// SupportedSockets lists the sockets a cooler fits, separated by commas
+	SupportedSockets string `gorm:"size:200" json:"supported_sockets"`
+	// Wattage is the rated output of a power supply
+	Wattage int32 `gorm:"not null;default:0" json:"wattage"`
+	// PowerDraw is the TDP of a CPU or GPU, or the estimated draw of any other part
+	PowerDraw int32 `gorm:"not null;default:0" json:"power_draw"`
+	// LengthMM is the length of a graphics card
+	LengthMM int32 `gorm:"column:length_mm;not null;default:0" json:"length_mm"`
+	// MaxGPULengthMM is the longest graphics card a case fits
+	MaxGPULengthMM int32     `gorm:"column:max_gpu_length_mm;not null;default:0" json:"max_gpu_length_mm"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
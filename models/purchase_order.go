@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Purchase order statuses. Orders move draft → sent → partially_received →
+// received; drafts and sent orders with nothing received can be cancelled.
+const (
+	PurchaseOrderDraft             = "draft"
+	PurchaseOrderSent              = "sent"
+	PurchaseOrderPartiallyReceived = "partially_received"
+	PurchaseOrderReceived          = "received"
+	PurchaseOrderCancelled         = "cancelled"
+)
+
+type PurchaseOrder struct {
+	gorm.Model
+	SupplierID uint                `gorm:"not null;index" json:"supplier_id"`
+	Supplier   Supplier            `gorm:"foreignKey:SupplierID" json:"supplier"`
+	Status     string              `gorm:"not null;size:25;index" json:"status"`
+	Currency   string              `gorm:"size:3;not null" json:"currency"`
+	Notes      string              `gorm:"size:255" json:"notes"`
+	CreatedBy  string              `gorm:"size:255" json:"created_by"`
+	SentAt     *time.Time          `json:"sent_at"`
+	ReceivedAt *time.Time          `json:"received_at"`
+	Lines      []PurchaseOrderLine `gorm:"foreignKey:PurchaseOrderID" json:"lines"`
+}
+
+type PurchaseOrderLine struct {
+	gorm.Model
+	PurchaseOrderID  uint    `gorm:"not null;index" json:"purchase_order_id"`
+	ProductID        uint    `gorm:"not null;index" json:"product_id"`
+	Product          Product `gorm:"foreignKey:ProductID" json:"product"`
+	Quantity         int32   `gorm:"not null" json:"quantity"`
+	ReceivedQuantity int32   `gorm:"not null;default:0" json:"received_quantity"`
+	UnitCost         Money   `gorm:"column:unit_cost_cents;not null" json:"unit_cost"`
+}
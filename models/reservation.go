@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Reservation statuses
+const (
+	ReservationActive    = "active"
+	ReservationConfirmed = "confirmed"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+)
+
+// Reservation holds units of a product for a limited time without taking them
+// out of stock. Confirming it turns it into a sale movement.
+type Reservation struct {
+	gorm.Model
+	ProductID       uint      `gorm:"not null;index" json:"product_id"`
+	Quantity        int32     `gorm:"not null" json:"quantity"`
+	Status          string    `gorm:"not null;size:20;index" json:"status"`
+	ExpiresAt       time.Time `gorm:"not null;index" json:"expires_at"`
+	UserEmail       string    `gorm:"size:255" json:"user_email"`
+	StockMovementID *uint     `json:"stock_movement_id"`
+}
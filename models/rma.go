@@ -0,0 +1,28 @@
+package models
+
+import "gorm.io/gorm"
+
+// RMA statuses. A return is requested, received and inspected, then either
+// restocked, which puts the units back in stock, or scrapped.
+const (
+	RMARequested = "requested"
+	RMAReceived  = "received"
+	RMAInspected = "inspected"
+	RMARestocked = "restocked"
+	RMAScrapped  = "scrapped"
+)
+
+// RMA tracks units a customer sends back, either against the order line they
+// were sold on or just against the product
+type RMA struct {
+	gorm.Model
+	ProductID        uint    `gorm:"not null;index" json:"product_id"`
+	Product          Product `gorm:"foreignKey:ProductID" json:"product"`
+	SalesOrderLineID *uint   `gorm:"index" json:"sales_order_line_id"`
+	Quantity         int32   `gorm:"not null" json:"quantity"`
+	Status           string  `gorm:"not null;size:20;index" json:"status"`
+	Reason           string  `gorm:"size:255" json:"reason"`
+	InspectionNotes  string  `gorm:"size:255" json:"inspection_notes"`
+	UserEmail        string  `gorm:"size:255;index" json:"user_email"`
+	StockMovementID  *uint   `json:"stock_movement_id"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "gorm.io/gorm"
+
+// Sales order statuses
+const (
+	SalesOrderCompleted = "completed"
+)
+
+// SalesOrder is a sale of one or more products. Its lines keep the price in
+// effect when the order was placed, converted to the order currency.
+type SalesOrder struct {
+	gorm.Model
+	UserEmail string           `gorm:"size:255;index" json:"user_email"`
+	Status    string           `gorm:"not null;size:20;index" json:"status"`
+	Currency  string           `gorm:"size:3;not null" json:"currency"`
+	Total     Money            `gorm:"column:total_cents;not null" json:"total"`
+	Lines     []SalesOrderLine `gorm:"foreignKey:SalesOrderID" json:"lines"`
+}
+
+type SalesOrderLine struct {
+	gorm.Model
+	SalesOrderID uint    `gorm:"not null;index" json:"sales_order_id"`
+	ProductID    uint    `gorm:"not null;index" json:"product_id"`
+	Product      Product `gorm:"foreignKey:ProductID" json:"product"`
+	WarehouseID  uint    `json:"warehouse_id"`
+	Quantity     int32   `gorm:"not null" json:"quantity"`
+	// ProductPrice and ProductCurrency are the catalog price at sale time,
+	// UnitPrice is that price in the order currency
+	ProductPrice    Money  `gorm:"column:product_price_cents;not null" json:"product_price"`
+	ProductCurrency string `gorm:"size:3;not null" json:"product_currency"`
+	ExchangeRateID  *uint  `json:"exchange_rate_id"`
+	UnitPrice       Money  `gorm:"column:unit_price_cents;not null" json:"unit_price"`
+	LineTotal       Money  `gorm:"column:line_total_cents;not null" json:"line_total"`
+	StockMovementID *uint  `json:"stock_movement_id"`
+}
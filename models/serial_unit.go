@@ -0,0 +1,26 @@
+package models
+
+import "gorm.io/gorm"
+
+// Serial unit statuses
+const (
+	SerialInStock    = "in_stock"
+	SerialSold       = "sold"
+	SerialReturned   = "returned"
+	SerialRMA        = "rma"
+	SerialWrittenOff = "written_off"
+)
+
+// SerialUnit is a single physical unit of a serialized product. The stock of a
+// serialized product always equals its units in stock, and the stock of each
+// warehouse equals the units in stock located there.
+type SerialUnit struct {
+	gorm.Model
+	ProductID        uint   `gorm:"not null;uniqueIndex:idx_serial_units_product_serial" json:"product_id"`
+	SerialNumber     string `gorm:"not null;size:100;uniqueIndex:idx_serial_units_product_serial;index" json:"serial_number"`
+	Status           string `gorm:"not null;size:20;index" json:"status"`
+	WarehouseID      uint   `gorm:"index" json:"warehouse_id"`
+	SalesOrderLineID *uint  `gorm:"index" json:"sales_order_line_id"`
+	RMAID            *uint  `gorm:"column:rma_id;index" json:"rma_id"`
+	LastMovementID   *uint  `json:"last_movement_id"`
+}
@@ -2,6 +2,12 @@ package models
 
 import "gorm.io/gorm"
 
+// Names of the statuses derived from the stock level
+const (
+	StatusInStock = "stock"
+	StatusSoldOut = "sold out"
+)
+
 type Status struct {
 	gorm.Model
 	Name string `gorm:"not null;unique;size:100" json:"name"`
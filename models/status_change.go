@@ -0,0 +1,15 @@
+package models
+
+import "gorm.io/gorm"
+
+// StatusChange records a product moving between statuses after a stock movement
+type StatusChange struct {
+	gorm.Model
+	ProductID       uint   `gorm:"not null;index" json:"product_id"`
+	FromStatusID    uint   `json:"from_status_id"`
+	FromStatus      Status `gorm:"foreignKey:FromStatusID" json:"from_status"`
+	ToStatusID      uint   `gorm:"not null" json:"to_status_id"`
+	ToStatus        Status `gorm:"foreignKey:ToStatusID" json:"to_status"`
+	StockMovementID uint   `json:"stock_movement_id"`
+	UserEmail       string `gorm:"size:255" json:"user_email"`
+}
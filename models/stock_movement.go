@@ -0,0 +1,39 @@
+package models
+
+import "gorm.io/gorm"
+
+// Reasons accepted for a stock movement
+const (
+	StockReasonPurchase   = "purchase"
+	StockReasonSale       = "sale"
+	StockReasonAdjustment = "adjustment"
+	StockReasonReturn     = "return"
+	StockReasonTransfer   = "transfer"
+)
+
+// Record types a stock movement can reference
+const (
+	ReferenceReservation       = "reservation"
+	ReferencePurchaseOrderLine = "purchase_order_line"
+	ReferenceSalesOrderLine    = "sales_order_line"
+	ReferenceRMA               = "rma"
+	ReferenceStocktake         = "stocktake"
+)
+
+// StockMovement is a single entry of the stock ledger. Summing the deltas of a
+// product rebuilds its stock level, and summing them per warehouse rebuilds
+// each warehouse level. A transfer is written as two movements that cancel out.
+type StockMovement struct {
+	gorm.Model
+	ProductID           uint   `gorm:"not null;index" json:"product_id"`
+	WarehouseID         uint   `gorm:"index" json:"warehouse_id"`
+	Delta               int32  `gorm:"not null" json:"delta"`
+	StockAfter          int32  `gorm:"not null" json:"stock_after"`
+	WarehouseStockAfter int32  `gorm:"not null;default:0" json:"warehouse_stock_after"`
+	Reason              string `gorm:"not null;size:25" json:"reason"`
+	// ReferenceType and ReferenceID point to the record that caused the
+	// movement, such as a purchase order line
+	ReferenceType string `gorm:"size:50;index:idx_stock_movements_reference" json:"reference_type,omitempty"`
+	ReferenceID   uint   `gorm:"index:idx_stock_movements_reference" json:"reference_id,omitempty"`
+	UserEmail     string `gorm:"size:255" json:"user_email"`
+}
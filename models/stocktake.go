@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Stocktake statuses
+const (
+	StocktakeOpen      = "open"
+	StocktakeCommitted = "committed"
+	StocktakeCancelled = "cancelled"
+)
+
+// Stocktake is a physical count of one warehouse, scoped to some categories
+// and their subcategories. Opening it snapshots the stock of every product in
+// scope; committing it adjusts the stock to the counted quantities.
+type Stocktake struct {
+	gorm.Model
+	Name        string          `gorm:"not null;size:100" json:"name"`
+	Status      string          `gorm:"not null;size:20;index" json:"status"`
+	WarehouseID uint            `gorm:"not null;index" json:"warehouse_id"`
+	Categories  []Category      `gorm:"many2many:stocktake_categories" json:"categories"`
+	Items       []StocktakeItem `gorm:"foreignKey:StocktakeID" json:"items,omitempty"`
+	OpenedBy    string          `gorm:"size:255" json:"opened_by"`
+	CommittedBy string          `gorm:"size:255" json:"committed_by"`
+	CommittedAt *time.Time      `json:"committed_at"`
+}
+
+// StocktakeItem is a product counted in a stocktake. ChangedDuringCount is set
+// when its stock moves while the stocktake is open, since the count may no
+// longer match the system stock.
+type StocktakeItem struct {
+	gorm.Model
+	StocktakeID        uint  `gorm:"not null;uniqueIndex:idx_stocktake_items_stocktake_product" json:"stocktake_id"`
+	ProductID          uint  `gorm:"not null;uniqueIndex:idx_stocktake_items_stocktake_product;index" json:"product_id"`
+	WarehouseID        uint  `gorm:"not null;index" json:"warehouse_id"`
+	SystemStock        int32 `gorm:"not null" json:"system_stock"`
+	ChangedDuringCount bool  `gorm:"not null;default:false" json:"changed_during_count"`
+	StockMovementID    *uint `json:"stock_movement_id"`
+}
+
+// StocktakeCount is the quantity of a product one counter found. A product's
+// counted quantity is the sum of the counts of every counter.
+type StocktakeCount struct {
+	gorm.Model
+	StocktakeID uint   `gorm:"not null;uniqueIndex:idx_stocktake_counts_counter" json:"stocktake_id"`
+	ProductID   uint   `gorm:"not null;uniqueIndex:idx_stocktake_counts_counter" json:"product_id"`
+	Counter     string `gorm:"not null;size:255;uniqueIndex:idx_stocktake_counts_counter" json:"counter"`
+	Quantity    int32  `gorm:"not null" json:"quantity"`
+}
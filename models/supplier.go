@@ -0,0 +1,11 @@
+package models
+
+import "gorm.io/gorm"
+
+type Supplier struct {
+	gorm.Model
+	Name        string `gorm:"not null;unique;size:100" json:"name"`
+	ContactName string `gorm:"size:100" json:"contact_name"`
+	Email       string `gorm:"size:255" json:"email"`
+	Phone       string `gorm:"size:30" json:"phone"`
+}
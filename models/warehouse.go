@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Warehouse is a storeroom where product units are kept. Stock changes that
+// do not name a warehouse go to the default one.
+type Warehouse struct {
+	gorm.Model
+	Name      string `gorm:"not null;unique;size:100" json:"name"`
+	Code      string `gorm:"not null;unique;size:20" json:"code"`
+	IsDefault bool   `gorm:"not null;default:false" json:"is_default"`
+}
+
+// WarehouseStock is the stock level of a product in one warehouse. The
+// product's own Stock is the sum of these levels.
+type WarehouseStock struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	ProductID   uint      `gorm:"not null;uniqueIndex:idx_warehouse_stocks_product_warehouse" json:"product_id"`
+	WarehouseID uint      `gorm:"not null;uniqueIndex:idx_warehouse_stocks_product_warehouse" json:"warehouse_id"`
+	Warehouse   Warehouse `gorm:"foreignKey:WarehouseID" json:"warehouse"`
+	Stock       int32     `gorm:"not null;default:0" json:"stock"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
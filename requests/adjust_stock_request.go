@@ -0,0 +1,11 @@
+package requests
+
+type AdjustStockRequest struct {
+	Delta       int32  `json:"delta" binding:"required"`
+	Reason      string `json:"reason" binding:"omitempty,oneof=purchase sale adjustment return"`
+	WarehouseID uint   `json:"warehouse_id"`
+	// Serials lists the units added or removed, for serialized products
+	Serials []string `json:"serials" binding:"omitempty,dive,required,max=100"`
+	// Lot describes where added units come from, for lot-tracked products
+	Lot *LotRequest `json:"lot"`
+}
@@ -0,0 +1,16 @@
+package requests
+
+type BuildValidateRequest struct {
+	// A product listed more than once counts as that many units
+	ProductIDs []uint `json:"product_ids" binding:"required,min=1,max=50"`
+}
+
+type BuildItemRequest struct {
+	ProductID uint  `json:"product_id" binding:"required"`
+	Quantity  int32 `json:"quantity" binding:"required,min=1"`
+}
+
+type BuildRequest struct {
+	Name  string             `json:"name" binding:"required,min=2,max=100"`
+	Items []BuildItemRequest `json:"items" binding:"required,min=1,max=50,dive"`
+}
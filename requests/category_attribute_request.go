@@ -0,0 +1,18 @@
+package requests
+
+type CategoryAttributeRequest struct {
+	Name          string   `json:"name" binding:"required,min=1,max=50"`
+	Type          string   `json:"type" binding:"required,oneof=string number integer boolean enum"`
+	Unit          string   `json:"unit" binding:"max=20"`
+	AllowedValues []string `json:"allowed_values" binding:"omitempty,max=100,dive,required,max=100"`
+	Required      bool     `json:"required"`
+}
+
+// UpdateCategoryAttributeRequest changes an attribute definition. The name is
+// fixed because products store their values under it.
+type UpdateCategoryAttributeRequest struct {
+	Type          string   `json:"type" binding:"required,oneof=string number integer boolean enum"`
+	Unit          string   `json:"unit" binding:"max=20"`
+	AllowedValues []string `json:"allowed_values" binding:"omitempty,max=100,dive,required,max=100"`
+	Required      bool     `json:"required"`
+}
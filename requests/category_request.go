@@ -0,0 +1,13 @@
+package requests
+
+type CategoryRequest struct {
+	Name                   string `json:"name" binding:"required,min=2,max=100"`
+	ParentID               *uint  `json:"parent_id" binding:"omitempty,min=1"`
+	DefaultMinStock        *int32 `json:"default_min_stock" binding:"omitempty,min=0"`
+	DefaultReorderQuantity *int32 `json:"default_reorder_quantity" binding:"omitempty,min=0"`
+	ComponentType          string `json:"component_type" binding:"omitempty,oneof=cpu motherboard memory gpu psu case cooler storage"`
+}
+
+type MoveCategoryRequest struct {
+	ParentID *uint `json:"parent_id" binding:"omitempty,min=1"`
+}
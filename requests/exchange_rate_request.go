@@ -0,0 +1,10 @@
+package requests
+
+import "time"
+
+type ExchangeRateRequest struct {
+	BaseCurrency  string     `json:"base_currency" binding:"required,iso4217"`
+	QuoteCurrency string     `json:"quote_currency" binding:"required,iso4217,nefield=BaseCurrency"`
+	Rate          string     `json:"rate" binding:"required"`
+	EffectiveFrom *time.Time `json:"effective_from"`
+}
@@ -0,0 +1,10 @@
+package requests
+
+type KitComponentRequest struct {
+	ProductID uint  `json:"product_id" binding:"required"`
+	Quantity  int32 `json:"quantity" binding:"required,min=1"`
+}
+
+type KitComponentsRequest struct {
+	Components []KitComponentRequest `json:"components" binding:"required,min=1,dive"`
+}
@@ -0,0 +1,10 @@
+package requests
+
+import "time"
+
+// LotRequest describes the lot units of a lot-tracked product are received into
+type LotRequest struct {
+	LotNumber         string     `json:"lot_number" binding:"max=100"`
+	SupplierReference string     `json:"supplier_reference" binding:"max=100"`
+	WarrantyExpiresAt *time.Time `json:"warranty_expires_at"`
+}
@@ -0,0 +1,23 @@
+package requests
+
+// PatchProductRequest holds the product fields that can be changed after
+// creation. Nil fields are left untouched.
+type PatchProductRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=2,max=100"`
+	Brand       *string `json:"brand" binding:"omitempty,min=2,max=50"`
+	Model2      *string `json:"model" binding:"omitempty,min=1,max=50"`
+	Description *string `json:"description" binding:"omitempty,min=5,max=255"`
+	Price       *string `json:"price" binding:"omitempty"`
+	Currency    *string `json:"currency" binding:"omitempty,iso4217"`
+	CategoryID  *string `json:"category_id" binding:"omitempty"`
+
+	MinStock        *int32 `json:"min_stock" binding:"omitempty,min=0"`
+	ReorderQuantity *int32 `json:"reorder_quantity" binding:"omitempty,min=0"`
+
+	// Serialized and LotTracked can only change while the product has no stock
+	Serialized *bool `json:"serialized"`
+	LotTracked *bool `json:"lot_tracked"`
+
+	// Attributes replaces every attribute value of the product
+	Attributes map[string]interface{} `json:"attributes"`
+}
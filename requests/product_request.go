@@ -7,6 +7,16 @@ type ProductRequest struct {
 	Description string `json:"description" binding:"required,min=5,max=255"`
 	Stock       string `json:"stock" binding:"required"`
 	Price       string `json:"price" binding:"required"`
-	StatusID    string `json:"status_id" binding:"required"`
+	Currency    string `json:"currency" binding:"omitempty,iso4217"`
 	CategoryID  string `json:"category_id" binding:"required"`
+	Serialized  bool   `json:"serialized"`
+	LotTracked  bool   `json:"lot_tracked"`
+	// IsKit creates a kit, whose components are set afterwards
+	IsKit bool `json:"is_kit"`
+	// Serials lists the units of the initial stock of a serialized product
+	Serials []string `json:"serials" binding:"omitempty,dive,required,max=100"`
+	// Lot describes the initial stock of a lot-tracked product
+	Lot *LotRequest `json:"lot"`
+	// Attributes are checked against the attribute schema of the category
+	Attributes map[string]interface{} `json:"attributes"`
 }
@@ -0,0 +1,14 @@
+package requests
+
+type ProductSpecRequest struct {
+	Socket               string `json:"socket" binding:"max=30"`
+	Chipset              string `json:"chipset" binding:"max=30"`
+	MemoryType           string `json:"memory_type" binding:"max=20"`
+	FormFactor           string `json:"form_factor" binding:"max=20"`
+	SupportedFormFactors string `json:"supported_form_factors" binding:"max=100"`
+	SupportedSockets     string `json:"supported_sockets" binding:"max=200"`
+	Wattage              int32  `json:"wattage" binding:"min=0"`
+	PowerDraw            int32  `json:"power_draw" binding:"min=0"`
+	LengthMM             int32  `json:"length_mm" binding:"min=0"`
+	MaxGPULengthMM       int32  `json:"max_gpu_length_mm" binding:"min=0"`
+}
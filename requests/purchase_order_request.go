@@ -0,0 +1,28 @@
+package requests
+
+type PurchaseOrderLineRequest struct {
+	ProductID uint   `json:"product_id" binding:"required"`
+	Quantity  int32  `json:"quantity" binding:"required,min=1"`
+	UnitCost  string `json:"unit_cost" binding:"required"`
+}
+
+type PurchaseOrderRequest struct {
+	SupplierID uint                       `json:"supplier_id" binding:"required"`
+	Currency   string                     `json:"currency" binding:"omitempty,iso4217"`
+	Notes      string                     `json:"notes" binding:"max=255"`
+	Lines      []PurchaseOrderLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+type ReceiveLineRequest struct {
+	LineID      uint  `json:"line_id" binding:"required"`
+	Quantity    int32 `json:"quantity" binding:"required,min=1"`
+	WarehouseID uint  `json:"warehouse_id"`
+	// Serials lists the units received, for serialized products
+	Serials []string `json:"serials" binding:"omitempty,dive,required,max=100"`
+	// Lot describes the lot received, for lot-tracked products
+	Lot *LotRequest `json:"lot"`
+}
+
+type ReceivePurchaseOrderRequest struct {
+	Lines []ReceiveLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
@@ -0,0 +1,12 @@
+package requests
+
+type ReservationRequest struct {
+	Quantity   int32 `json:"quantity" binding:"required,min=1"`
+	TTLSeconds int   `json:"ttl_seconds" binding:"omitempty,min=60,max=86400"`
+}
+
+type ConfirmReservationRequest struct {
+	WarehouseID uint `json:"warehouse_id"`
+	// Serials lists the units sold, for serialized products
+	Serials []string `json:"serials" binding:"omitempty,dive,required,max=100"`
+}
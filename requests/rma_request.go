@@ -0,0 +1,16 @@
+package requests
+
+type RMARequest struct {
+	SalesOrderLineID uint   `json:"sales_order_line_id" binding:"required_without=ProductID"`
+	ProductID        uint   `json:"product_id" binding:"required_without=SalesOrderLineID"`
+	Quantity         int32  `json:"quantity" binding:"required,min=1"`
+	Reason           string `json:"reason" binding:"required,max=255"`
+	// Serials lists the units returned, for serialized products
+	Serials []string `json:"serials" binding:"omitempty,dive,required,max=100"`
+}
+
+type RMAStatusRequest struct {
+	Status      string `json:"status" binding:"required,oneof=received inspected restocked scrapped"`
+	Notes       string `json:"notes" binding:"max=255"`
+	WarehouseID uint   `json:"warehouse_id"`
+}
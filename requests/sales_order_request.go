@@ -0,0 +1,14 @@
+package requests
+
+type SalesOrderLineRequest struct {
+	ProductID   uint  `json:"product_id" binding:"required"`
+	Quantity    int32 `json:"quantity" binding:"required,min=1"`
+	WarehouseID uint  `json:"warehouse_id"`
+	// Serials lists the units sold, for serialized products
+	Serials []string `json:"serials" binding:"omitempty,dive,required,max=100"`
+}
+
+type SalesOrderRequest struct {
+	Currency string                  `json:"currency" binding:"omitempty,iso4217"`
+	Lines    []SalesOrderLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
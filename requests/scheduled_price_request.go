@@ -0,0 +1,9 @@
+package requests
+
+import "time"
+
+type ScheduledPriceRequest struct {
+	Price       string    `json:"price" binding:"required"`
+	Currency    string    `json:"currency" binding:"omitempty,iso4217"`
+	EffectiveAt time.Time `json:"effective_at" binding:"required"`
+}
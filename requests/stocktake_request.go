@@ -0,0 +1,21 @@
+package requests
+
+type StocktakeRequest struct {
+	Name        string `json:"name" binding:"required,min=2,max=100"`
+	CategoryIDs []uint `json:"category_ids" binding:"required,min=1"`
+	WarehouseID uint   `json:"warehouse_id"`
+}
+
+type StocktakeCountRequest struct {
+	ProductID uint   `json:"product_id" binding:"required"`
+	Quantity  *int32 `json:"quantity" binding:"required,min=0"`
+}
+
+type StocktakeCountsRequest struct {
+	Counts []StocktakeCountRequest `json:"counts" binding:"required,min=1,dive"`
+}
+
+type CommitStocktakeRequest struct {
+	// ExcludeChanged leaves out products whose stock moved during the count
+	ExcludeChanged bool `json:"exclude_changed"`
+}
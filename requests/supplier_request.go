@@ -0,0 +1,8 @@
+package requests
+
+type SupplierRequest struct {
+	Name        string `json:"name" binding:"required,min=2,max=100"`
+	ContactName string `json:"contact_name" binding:"max=100"`
+	Email       string `json:"email" binding:"omitempty,email"`
+	Phone       string `json:"phone" binding:"max=30"`
+}
@@ -0,0 +1,15 @@
+package requests
+
+type WarehouseRequest struct {
+	Name      string `json:"name" binding:"required,min=2,max=100"`
+	Code      string `json:"code" binding:"required,min=1,max=20"`
+	IsDefault bool   `json:"is_default"`
+}
+
+type TransferStockRequest struct {
+	FromWarehouseID uint  `json:"from_warehouse_id" binding:"required"`
+	ToWarehouseID   uint  `json:"to_warehouse_id" binding:"required,nefield=FromWarehouseID"`
+	Quantity        int32 `json:"quantity" binding:"required,min=1"`
+	// Serials lists the units moved, for serialized products
+	Serials []string `json:"serials" binding:"omitempty,dive,required,max=100"`
+}
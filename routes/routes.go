@@ -13,6 +13,24 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 	// Initialize handlers
 	productHandler := handlers.NewProductHandler(db)
 	authHandler := handlers.NewAuthHandler(db)
+	categoryHandler := handlers.NewCategoryHandler(db)
+	exchangeRateHandler := handlers.NewExchangeRateHandler(db)
+	priceHandler := handlers.NewPriceHandler(db)
+	warehouseHandler := handlers.NewWarehouseHandler(db)
+	reservationHandler := handlers.NewReservationHandler(db)
+	reportHandler := handlers.NewReportHandler(db)
+	alertHandler := handlers.NewAlertHandler(db)
+	supplierHandler := handlers.NewSupplierHandler(db)
+	purchaseOrderHandler := handlers.NewPurchaseOrderHandler(db)
+	salesOrderHandler := handlers.NewSalesOrderHandler(db)
+	rmaHandler := handlers.NewRMAHandler(db)
+	serialHandler := handlers.NewSerialHandler(db)
+	lotHandler := handlers.NewLotHandler(db)
+	stocktakeHandler := handlers.NewStocktakeHandler(db)
+	kitHandler := handlers.NewKitHandler(db)
+	specHandler := handlers.NewSpecHandler(db)
+	buildHandler := handlers.NewBuildHandler(db)
+	categoryAttributeHandler := handlers.NewCategoryAttributeHandler(db)
 	
 	// Initialize Casbin enforcer
 	enforcer, err := casbin.NewEnforcer("model.conf", "policy.csv")
@@ -26,10 +44,97 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 	// Protected routes (require authentication and authorization)
 	api := router.Group("/api/v1", middlewares.AuthMiddleware(), middlewares.CasbinMiddleware(enforcer))
 	{
+		api.GET("/products", productHandler.List)
+		api.GET("/products/:id", productHandler.GetProduct)
 		api.POST("/products", productHandler.CreateProduct)
-		api.PUT("/products/:id", productHandler.UpdateProduct)
+		api.PATCH("/products/:id", productHandler.UpdateProduct)
 		api.PUT("/products/:id/stock", productHandler.UpdateStock)
+		api.POST("/products/:id/stock/adjust", productHandler.AdjustStock)
+		api.GET("/products/:id/movements", productHandler.GetMovements)
+		api.GET("/products/:id/status-changes", productHandler.GetStatusChanges)
 		api.DELETE("/products/:id", productHandler.Delete)
+		api.GET("/products/trash", productHandler.ListTrash)
+		api.POST("/products/:id/restore", productHandler.Restore)
+		api.DELETE("/products/:id/purge", productHandler.Purge)
+		api.GET("/products/:id/stock-levels", warehouseHandler.StockLevels)
+		api.POST("/products/:id/transfers", warehouseHandler.Transfer)
+		api.POST("/products/:id/reservations", reservationHandler.Create)
+		api.GET("/products/:id/prices", priceHandler.GetHistory)
+		api.GET("/products/:id/scheduled-prices", priceHandler.ListScheduled)
+		api.POST("/products/:id/scheduled-prices", priceHandler.Schedule)
+		api.DELETE("/products/:id/scheduled-prices/:scheduleId", priceHandler.CancelScheduled)
+		api.GET("/products/:id/purchase-orders", purchaseOrderHandler.ProductOrders)
+		api.GET("/products/:id/rmas", rmaHandler.ProductRMAs)
+		api.GET("/products/:id/serials", serialHandler.ProductSerials)
+		api.GET("/serials/:serial", serialHandler.Lookup)
+		api.GET("/products/:id/lots", lotHandler.ProductLots)
+		api.GET("/lots/:id/movements", lotHandler.Movements)
+		api.PUT("/products/:id/components", kitHandler.SetComponents)
+		api.PUT("/products/:id/spec", specHandler.Set)
+
+		api.POST("/categories", categoryHandler.Create)
+		api.PUT("/categories/:id", categoryHandler.Update)
+		api.PUT("/categories/:id/parent", categoryHandler.Move)
+		api.POST("/categories/:id/attributes", categoryAttributeHandler.Create)
+		api.PUT("/categories/:id/attributes/:attributeId", categoryAttributeHandler.Update)
+		api.DELETE("/categories/:id/attributes/:attributeId", categoryAttributeHandler.Delete)
+		api.DELETE("/categories/:id", categoryHandler.Delete)
+		api.POST("/categories/:id/restore", categoryHandler.Restore)
+
+		api.GET("/reservations", reservationHandler.List)
+		api.POST("/reservations/:id/confirm", reservationHandler.Confirm)
+		api.POST("/reservations/:id/release", reservationHandler.Release)
+
+		api.GET("/orders", salesOrderHandler.List)
+		api.GET("/orders/:id", salesOrderHandler.Get)
+		api.POST("/orders", salesOrderHandler.Create)
+
+		api.GET("/builds", buildHandler.List)
+		api.GET("/builds/:id", buildHandler.Get)
+		api.POST("/builds", buildHandler.Create)
+		api.PUT("/builds/:id", buildHandler.Update)
+		api.DELETE("/builds/:id", buildHandler.Delete)
+		api.GET("/builds/:id/quote", buildHandler.Quote)
+		api.POST("/builds/:id/share", buildHandler.Share)
+		api.DELETE("/builds/:id/share", buildHandler.Unshare)
+
+		api.GET("/rmas", rmaHandler.List)
+		api.GET("/rmas/:id", rmaHandler.Get)
+		api.POST("/rmas", rmaHandler.Create)
+		api.PUT("/rmas/:id/status", rmaHandler.UpdateStatus)
+
+		api.GET("/stocktakes", stocktakeHandler.List)
+		api.GET("/stocktakes/:id", stocktakeHandler.Get)
+		api.POST("/stocktakes", stocktakeHandler.Create)
+		api.POST("/stocktakes/:id/counts", stocktakeHandler.SubmitCounts)
+		api.GET("/stocktakes/:id/variance", stocktakeHandler.Variance)
+		api.POST("/stocktakes/:id/commit", stocktakeHandler.Commit)
+		api.POST("/stocktakes/:id/cancel", stocktakeHandler.Cancel)
+
+		api.GET("/warehouses", warehouseHandler.List)
+		api.POST("/warehouses", warehouseHandler.Create)
+		api.PUT("/warehouses/:id", warehouseHandler.Update)
+
+		api.GET("/suppliers", supplierHandler.List)
+		api.GET("/suppliers/:id", supplierHandler.Get)
+		api.POST("/suppliers", supplierHandler.Create)
+		api.PUT("/suppliers/:id", supplierHandler.Update)
+
+		api.GET("/purchase-orders", purchaseOrderHandler.List)
+		api.GET("/purchase-orders/:id", purchaseOrderHandler.Get)
+		api.POST("/purchase-orders", purchaseOrderHandler.Create)
+		api.PUT("/purchase-orders/:id", purchaseOrderHandler.Update)
+		api.POST("/purchase-orders/:id/send", purchaseOrderHandler.Send)
+		api.POST("/purchase-orders/:id/receive", purchaseOrderHandler.Receive)
+		api.POST("/purchase-orders/:id/cancel", purchaseOrderHandler.Cancel)
+
+		api.GET("/reports/low-stock", reportHandler.LowStock)
+		api.GET("/reports/warranty-expiring", reportHandler.WarrantyExpiring)
+		api.GET("/alerts/low-stock", alertHandler.ListLowStock)
+		api.POST("/alerts/low-stock/:id/acknowledge", alertHandler.AcknowledgeLowStock)
+
+		api.GET("/exchange-rates", exchangeRateHandler.List)
+		api.POST("/exchange-rates", exchangeRateHandler.Create)
 	}
 
 	// Public routes (no authentication required)
@@ -38,6 +143,12 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 		publicAPI.POST("/register", authHandler.Register)
 		publicAPI.POST("/login", authHandler.Login)
 		publicAPI.GET("/products/search", productHandler.GetByProperty) // Public search endpoint
+		publicAPI.GET("/categories", categoryHandler.List)
+		publicAPI.GET("/categories/tree", categoryHandler.Tree)
+		publicAPI.GET("/categories/:id", categoryHandler.Get)
+		publicAPI.GET("/categories/:id/attributes", categoryAttributeHandler.List)
+		publicAPI.POST("/builds/validate", buildHandler.Validate)
+		publicAPI.GET("/shared-builds/:token", buildHandler.Shared)
 	}
 
 	return router
@@ -0,0 +1,108 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/lumiere11/pc-inventory-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StartPriceScheduler applies due scheduled prices every interval until the
+// context is cancelled
+func StartPriceScheduler(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			applyDuePrices(ctx, db)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// applyDuePrices applies every pending scheduled price whose time has come,
+// oldest first, so the latest schedule wins when several are due at once
+func applyDuePrices(ctx context.Context, db *gorm.DB) {
+	var due []models.ScheduledPrice
+	if err := db.WithContext(ctx).
+		Where("applied_at IS NULL AND cancelled_at IS NULL AND effective_at <= ?", time.Now()).
+		Order("effective_at").
+		Order("id").
+		Find(&due).Error; err != nil {
+		log.Printf("price scheduler: failed to load scheduled prices: %v", err)
+		return
+	}
+
+	for _, schedule := range due {
+		if err := applyScheduledPrice(ctx, db, schedule.ID); err != nil {
+			log.Printf("price scheduler: failed to apply scheduled price %d: %v", schedule.ID, err)
+		}
+	}
+}
+
+// applyScheduledPrice updates the product price and records the change in a
+// single transaction
+func applyScheduledPrice(ctx context.Context, db *gorm.DB, scheduleID uint) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var schedule models.ScheduledPrice
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Where("applied_at IS NULL AND cancelled_at IS NULL").
+			First(&schedule, scheduleID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// Cancelled or applied by another run in the meantime
+				return nil
+			}
+			return err
+		}
+
+		now := time.Now()
+		var product models.Product
+		err := tx.Unscoped().Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).First(&product, schedule.ProductID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// The product was purged, there is nothing to apply the price to
+			return tx.Model(&schedule).Update("cancelled_at", now).Error
+		}
+		if err != nil {
+			return err
+		}
+		if product.DeletedAt.Valid {
+			// A product in the trash may still be restored, so the price waits
+			// for it and applies on the first run after the restore
+			return nil
+		}
+
+		if err := tx.Model(&product).Updates(map[string]interface{}{
+			"price_cents": schedule.Price,
+			"currency":    schedule.Currency,
+			"version":     gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+
+		change := models.PriceChange{
+			ProductID:        product.ID,
+			OldPrice:         product.Price,
+			OldCurrency:      product.Currency,
+			NewPrice:         schedule.Price,
+			NewCurrency:      schedule.Currency,
+			Source:           models.PriceSourceScheduled,
+			ScheduledPriceID: &schedule.ID,
+			UserEmail:        schedule.CreatedBy,
+		}
+		if err := tx.Create(&change).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&schedule).Update("applied_at", now).Error
+	})
+}
@@ -0,0 +1,43 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/lumiere11/pc-inventory-go/models"
+	"gorm.io/gorm"
+)
+
+// StartReservationSweeper marks active reservations past their expiry as
+// expired every interval until the context is cancelled
+func StartReservationSweeper(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			expireReservations(ctx, db)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// expireReservations releases the units held by every reservation whose TTL ran out
+func expireReservations(ctx context.Context, db *gorm.DB) {
+	result := db.WithContext(ctx).Model(&models.Reservation{}).
+		Where("status = ? AND expires_at <= ?", models.ReservationActive, time.Now()).
+		Update("status", models.ReservationExpired)
+	if result.Error != nil {
+		log.Printf("reservation sweeper: failed to expire reservations: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("reservation sweeper: expired %d reservations", result.RowsAffected)
+	}
+}